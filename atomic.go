@@ -0,0 +1,78 @@
+package jsonapi
+
+// AtomicExtension is the URI of the official atomic operations extension.
+//
+// See: https://jsonapi.org/ext/atomic.
+const AtomicExtension = "https://jsonapi.org/ext/atomic"
+
+// atomicMediaType is the media type used by requests and responses that apply
+// the atomic operations extension.
+const atomicMediaType = MediaType + `; ext="` + AtomicExtension + `"`
+
+// OperationCode is the code of an atomic operation.
+type OperationCode string
+
+const (
+	// AddOperation adds a resource or members to a relationship.
+	AddOperation OperationCode = "add"
+
+	// UpdateOperation updates a resource or replaces a relationship.
+	UpdateOperation OperationCode = "update"
+
+	// RemoveOperation removes a resource or members from a relationship.
+	RemoveOperation OperationCode = "remove"
+)
+
+// An Operation is a single operation of an atomic operations request.
+//
+// See: https://jsonapi.org/ext/atomic/#operation-objects.
+type Operation struct {
+	// The code of the operation.
+	Op OperationCode `json:"op"`
+
+	// The target of the operation.
+	Ref *Reference `json:"ref,omitempty"`
+
+	// The URI reference of the target of the operation.
+	Href string `json:"href,omitempty"`
+
+	// The primary data of the operation.
+	Data *HybridResource `json:"data,omitempty"`
+
+	// Non-standard meta-information about the operation.
+	//
+	// Note: Numbers are left as strings to avoid issues with mismatching types
+	// when they are later assigned to a struct.
+	Meta Map `json:"meta,omitempty"`
+}
+
+// A Reference identifies the target of an operation.
+//
+// See: https://jsonapi.org/ext/atomic/#operation-objects.
+type Reference struct {
+	// The type of the targeted resource.
+	Type string `json:"type"`
+
+	// The id of the targeted resource.
+	ID string `json:"id,omitempty"`
+
+	// The local id of the targeted resource.
+	LID string `json:"lid,omitempty"`
+
+	// The targeted relationship of the resource.
+	Relationship string `json:"relationship,omitempty"`
+}
+
+// A Result is the result of a single successful operation.
+//
+// See: https://jsonapi.org/ext/atomic/#result-objects.
+type Result struct {
+	// The primary data resulting from the operation.
+	Data *HybridResource `json:"data,omitempty"`
+
+	// Non-standard meta-information about the result.
+	//
+	// Note: Numbers are left as strings to avoid issues with mismatching types
+	// when they are later assigned to a struct.
+	Meta Map `json:"meta,omitempty"`
+}
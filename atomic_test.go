@@ -0,0 +1,81 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDocumentAtomicOperations(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(`{
+		"atomic:operations": [{
+			"op": "add",
+			"href": "/posts",
+			"data": {
+				"type": "posts",
+				"attributes": {
+					"title": "Hello"
+				}
+			}
+		}, {
+			"op": "remove",
+			"ref": {
+				"type": "posts",
+				"id": "1"
+			}
+		}]
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &Document{
+		Operations: []*Operation{
+			{
+				Op:   AddOperation,
+				Href: "/posts",
+				Data: &HybridResource{
+					One: &Resource{
+						Type: "posts",
+						Attributes: Map{
+							"title": "Hello",
+						},
+					},
+				},
+			},
+			{
+				Op: RemoveOperation,
+				Ref: &Reference{
+					Type: "posts",
+					ID:   "1",
+				},
+			},
+		},
+	}, doc)
+}
+
+func TestWriteResponseAtomicResults(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", atomicMediaType)
+
+	err := WriteResponse(rec, http.StatusOK, &Document{
+		Results: []*Result{
+			{
+				Data: &HybridResource{
+					One: &Resource{Type: "posts", ID: "1"},
+				},
+			},
+			{},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, atomicMediaType, rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"atomic:results": [{
+			"data": {
+				"type": "posts",
+				"id": "1"
+			}
+		}, {}]
+	}`, rec.Body.String())
+}
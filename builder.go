@@ -0,0 +1,319 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// resourceKey uniquely identifies a resource in a document.
+type resourceKey struct {
+	typ string
+	id  string
+	lid string
+}
+
+// keyOf will return the key of the passed resource and whether it can be
+// identified at all. Resources are identified by their id or local id.
+func keyOf(res *Resource) (resourceKey, bool) {
+	// check id
+	if res.ID != "" {
+		return resourceKey{typ: res.Type, id: res.ID}, true
+	}
+
+	return resourceKey{typ: res.Type, lid: res.LID}, res.LID != ""
+}
+
+type builderEntry struct {
+	res     *Resource
+	primary bool
+	merged  bool
+}
+
+// DocumentBuilder is used to build compound documents. It collects primary
+// data and included resources, merges resources that are added multiple times
+// and drops included resources that already appear in the primary data.
+//
+// The zero value is ready to use.
+type DocumentBuilder struct {
+	// The links added to the document.
+	Links *DocumentLinks
+
+	// The meta added to the document.
+	Meta Map
+
+	// Whether the built document should be validated using Document.Validate.
+	Strict bool
+
+	one      bool
+	many     bool
+	data     []*builderEntry
+	included []*builderEntry
+	entries  map[resourceKey]*builderEntry
+	err      error
+}
+
+// One will set the passed resource as the single primary resource of the
+// document. A nil resource will result in null primary data, also if a
+// resource has been set before.
+func (b *DocumentBuilder) One(res *Resource) {
+	// check mode
+	if b.many {
+		b.fail(errors.New("cannot mix single and multiple primary resources"))
+		return
+	}
+
+	// check existing
+	if b.one && len(b.data) > 0 && res != nil {
+		key1, ok1 := keyOf(b.data[0].res)
+		key2, ok2 := keyOf(res)
+		if !ok1 || !ok2 || key1 != key2 {
+			b.fail(errors.New("cannot set multiple single primary resources"))
+			return
+		}
+	}
+
+	// set mode
+	b.one = true
+
+	// handle null
+	if res == nil {
+		b.clearData()
+		return
+	}
+
+	// add resource
+	b.add(res, true)
+}
+
+// Many will add the passed resources to the primary data of the document.
+// Calling it without resources will result in an empty list.
+func (b *DocumentBuilder) Many(res ...*Resource) {
+	// check mode
+	if b.one {
+		b.fail(errors.New("cannot mix single and multiple primary resources"))
+		return
+	}
+
+	// set mode
+	b.many = true
+
+	// add resources
+	for _, r := range res {
+		b.add(r, true)
+	}
+}
+
+// Include will add the passed resources to the included resources of the
+// document. Resources that are already part of the primary data or included
+// resources are merged with the existing resource.
+func (b *DocumentBuilder) Include(res ...*Resource) {
+	for _, r := range res {
+		b.add(r, false)
+	}
+}
+
+// Build will return the built document or the first error encountered while
+// adding resources or validating the document.
+func (b *DocumentBuilder) Build() (*Document, error) {
+	// check error
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	// prepare document
+	doc := &Document{
+		Links: b.Links,
+		Meta:  b.Meta,
+	}
+
+	// set data
+	if b.one {
+		doc.Data = &HybridResource{}
+		if len(b.data) > 0 {
+			doc.Data.One = b.data[0].res
+		}
+	} else if b.many {
+		doc.Data = &HybridResource{
+			Many: make([]*Resource, 0, len(b.data)),
+		}
+		for _, entry := range b.data {
+			doc.Data.Many = append(doc.Data.Many, entry.res)
+		}
+	}
+
+	// set included
+	for _, entry := range b.included {
+		if !entry.primary {
+			doc.Included = append(doc.Included, entry.res)
+		}
+	}
+
+	// validate document
+	if b.Strict {
+		if errs := doc.Validate(); len(errs) > 0 {
+			return nil, errs[0]
+		}
+	}
+
+	return doc, nil
+}
+
+func (b *DocumentBuilder) add(res *Resource, primary bool) {
+	// check resource
+	if res == nil {
+		b.fail(errors.New("cannot add nil resource"))
+		return
+	} else if res.Type == "" {
+		b.fail(errors.New("cannot add resource without type"))
+		return
+	}
+
+	// get key
+	key, ok := keyOf(res)
+	if !ok {
+		// only new primary resources may be anonymous
+		if !primary {
+			b.fail(fmt.Errorf("cannot include resource of type %q without id or lid", res.Type))
+			return
+		}
+
+		// add primary resource
+		b.data = append(b.data, &builderEntry{res: res, primary: true})
+
+		return
+	}
+
+	// ensure index
+	if b.entries == nil {
+		b.entries = map[resourceKey]*builderEntry{}
+	}
+
+	// get existing entry
+	entry, ok := b.entries[key]
+	if !ok {
+		// add entry
+		entry = &builderEntry{res: res, primary: primary}
+		b.entries[key] = entry
+		if primary {
+			b.data = append(b.data, entry)
+		} else {
+			b.included = append(b.included, entry)
+		}
+
+		return
+	}
+
+	// merge resource
+	entry.merge(res)
+
+	// promote included resource
+	if primary && !entry.primary {
+		entry.primary = true
+		b.data = append(b.data, entry)
+	}
+}
+
+// clearData will remove the primary data. Resources that have also been
+// included are kept as included resources.
+func (b *DocumentBuilder) clearData() {
+	for _, entry := range b.data {
+		// check key
+		key, ok := keyOf(entry.res)
+		if !ok {
+			continue
+		}
+
+		// keep included resources
+		included := false
+		for _, e := range b.included {
+			if e == entry {
+				included = true
+			}
+		}
+		if included {
+			entry.primary = false
+			continue
+		}
+
+		// remove entry
+		delete(b.entries, key)
+	}
+
+	// clear data
+	b.data = nil
+}
+
+func (b *DocumentBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+func (e *builderEntry) merge(res *Resource) {
+	// check identity
+	if e.res == res {
+		return
+	}
+
+	// copy resource once to keep the added resources unchanged
+	if !e.merged {
+		e.res = copyResource(e.res)
+		e.merged = true
+	}
+
+	// merge attributes
+	for name, value := range res.Attributes {
+		if e.res.Attributes == nil {
+			e.res.Attributes = Map{}
+		}
+		e.res.Attributes[name] = value
+	}
+
+	// merge relationships
+	for name, rel := range res.Relationships {
+		if e.res.Relationships == nil {
+			e.res.Relationships = map[string]*Relationship{}
+		}
+		e.res.Relationships[name] = rel
+	}
+
+	// merge meta
+	for name, value := range res.Meta {
+		if e.res.Meta == nil {
+			e.res.Meta = Map{}
+		}
+		e.res.Meta[name] = value
+	}
+}
+
+// copyResource will return a shallow copy of the resource with copied maps.
+func copyResource(res *Resource) *Resource {
+	// copy resource
+	cpy := *res
+
+	// copy attributes
+	if res.Attributes != nil {
+		cpy.Attributes = make(Map, len(res.Attributes))
+		for name, value := range res.Attributes {
+			cpy.Attributes[name] = value
+		}
+	}
+
+	// copy relationships
+	if res.Relationships != nil {
+		cpy.Relationships = make(map[string]*Relationship, len(res.Relationships))
+		for name, rel := range res.Relationships {
+			cpy.Relationships[name] = rel
+		}
+	}
+
+	// copy meta
+	if res.Meta != nil {
+		cpy.Meta = make(Map, len(res.Meta))
+		for name, value := range res.Meta {
+			cpy.Meta[name] = value
+		}
+	}
+
+	return &cpy
+}
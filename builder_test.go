@@ -0,0 +1,244 @@
+package jsonapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentBuilderEmpty(t *testing.T) {
+	var b DocumentBuilder
+
+	doc, err := b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, &Document{}, doc)
+
+	b.One(nil)
+
+	doc, err = b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, &Document{
+		Data: &HybridResource{},
+	}, doc)
+
+	b = DocumentBuilder{}
+	b.Many()
+
+	doc, err = b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, &Document{
+		Data: &HybridResource{
+			Many: []*Resource{},
+		},
+	}, doc)
+}
+
+func TestDocumentBuilderOne(t *testing.T) {
+	b := DocumentBuilder{
+		Links: &DocumentLinks{
+			Self: &Link{Href: "/posts/1"},
+		},
+		Meta: Map{
+			"foo": "bar",
+		},
+	}
+
+	b.One(&Resource{Type: "posts", ID: "1"})
+	b.Include(&Resource{Type: "users", ID: "1"})
+	b.Include(&Resource{Type: "posts", ID: "1"})
+
+	doc, err := b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, &Document{
+		Data: &HybridResource{
+			One: &Resource{Type: "posts", ID: "1"},
+		},
+		Included: []*Resource{
+			{Type: "users", ID: "1"},
+		},
+		Links: &DocumentLinks{
+			Self: &Link{Href: "/posts/1"},
+		},
+		Meta: Map{
+			"foo": "bar",
+		},
+	}, doc)
+}
+
+func TestDocumentBuilderMany(t *testing.T) {
+	var b DocumentBuilder
+
+	user := &Resource{
+		Type: "users",
+		ID:   "1",
+		Attributes: Map{
+			"name": "Joe",
+		},
+	}
+
+	b.Include(user, &Resource{Type: "comments", ID: "1"})
+	b.Many(&Resource{Type: "posts", ID: "1"}, &Resource{Type: "posts", ID: "2"})
+	b.Include(&Resource{
+		Type: "users",
+		ID:   "1",
+		Attributes: Map{
+			"age": 42,
+		},
+		Relationships: map[string]*Relationship{
+			"posts": {},
+		},
+		Meta: Map{
+			"foo": "bar",
+		},
+	})
+	b.Many(&Resource{Type: "comments", ID: "1"})
+	b.Many(&Resource{Type: "posts"})
+
+	doc, err := b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, &Document{
+		Data: &HybridResource{
+			Many: []*Resource{
+				{Type: "posts", ID: "1"},
+				{Type: "posts", ID: "2"},
+				{Type: "comments", ID: "1"},
+				{Type: "posts"},
+			},
+		},
+		Included: []*Resource{
+			{
+				Type: "users",
+				ID:   "1",
+				Attributes: Map{
+					"name": "Joe",
+					"age":  42,
+				},
+				Relationships: map[string]*Relationship{
+					"posts": {},
+				},
+				Meta: Map{
+					"foo": "bar",
+				},
+			},
+		},
+	}, doc)
+
+	assert.Equal(t, &Resource{
+		Type: "users",
+		ID:   "1",
+		Attributes: Map{
+			"name": "Joe",
+		},
+	}, user)
+}
+
+func TestDocumentBuilderOneNull(t *testing.T) {
+	var b DocumentBuilder
+	b.One(&Resource{Type: "posts", ID: "1"})
+	b.Include(&Resource{Type: "users", ID: "1"})
+	b.One(nil)
+
+	doc, err := b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, &Document{
+		Data: &HybridResource{},
+		Included: []*Resource{
+			{Type: "users", ID: "1"},
+		},
+	}, doc)
+
+	b = DocumentBuilder{}
+	b.Include(&Resource{Type: "posts", ID: "1"})
+	b.One(&Resource{Type: "posts", ID: "1"})
+	b.One(nil)
+
+	doc, err = b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, &Document{
+		Data: &HybridResource{},
+		Included: []*Resource{
+			{Type: "posts", ID: "1"},
+		},
+	}, doc)
+
+	b = DocumentBuilder{}
+	b.One(&Resource{Type: "posts", ID: "1"})
+	b.One(nil)
+	b.One(&Resource{Type: "posts", ID: "2"})
+
+	doc, err = b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, &Document{
+		Data: &HybridResource{
+			One: &Resource{Type: "posts", ID: "2"},
+		},
+	}, doc)
+}
+
+func TestDocumentBuilderErrors(t *testing.T) {
+	var b DocumentBuilder
+	b.One(&Resource{Type: "posts", ID: "1"})
+	b.Many(&Resource{Type: "posts", ID: "2"})
+	_, err := b.Build()
+	assert.Error(t, err)
+
+	b = DocumentBuilder{}
+	b.Many(&Resource{Type: "posts", ID: "2"})
+	b.One(&Resource{Type: "posts", ID: "1"})
+	_, err = b.Build()
+	assert.Error(t, err)
+
+	b = DocumentBuilder{}
+	b.One(&Resource{Type: "posts", ID: "1"})
+	b.One(&Resource{Type: "posts", ID: "2"})
+	_, err = b.Build()
+	assert.Error(t, err)
+
+	b = DocumentBuilder{}
+	b.Include(&Resource{Type: "posts"})
+	_, err = b.Build()
+	assert.Error(t, err)
+
+	b = DocumentBuilder{}
+	b.Include(&Resource{Type: "posts", LID: "a"})
+	b.Include(&Resource{Type: "posts", LID: "a"})
+	doc, err := b.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Resource{{Type: "posts", LID: "a"}}, doc.Included)
+
+	b = DocumentBuilder{}
+	b.Include(&Resource{ID: "1"})
+	_, err = b.Build()
+	assert.Error(t, err)
+
+	b = DocumentBuilder{}
+	b.Include(nil)
+	_, err = b.Build()
+	assert.Error(t, err)
+}
+
+func TestDocumentBuilderStrict(t *testing.T) {
+	b := DocumentBuilder{Strict: true}
+	b.One(&Resource{
+		Type: "posts",
+		ID:   "1",
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{
+					One: &Resource{Type: "users", ID: "1"},
+				},
+			},
+		},
+	})
+	b.Include(&Resource{Type: "users", ID: "1"})
+
+	doc, err := b.Build()
+	assert.NoError(t, err)
+	assert.NotNil(t, doc)
+
+	b.Include(&Resource{Type: "users", ID: "2"})
+
+	doc, err = b.Build()
+	assert.Equal(t, BadRequestPointer("included resource is not linked", "/included/1"), err)
+	assert.Nil(t, doc)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"strings"
 )
@@ -14,9 +15,10 @@ import (
 
 // ClientConfig is used to configure a client.
 type ClientConfig struct {
-	BaseURI       string
-	Authorizer    func(*http.Request)
-	ResponseLimit int64
+	BaseURI        string
+	Authorizer     func(*http.Request)
+	ResponseLimit  int64
+	AtomicEndpoint string
 }
 
 // Client is a low-level jsonapi client.
@@ -41,6 +43,11 @@ func NewClientWithClient(config ClientConfig, client *http.Client) *Client {
 		config.ResponseLimit = 8192
 	}
 
+	// set default atomic endpoint
+	if config.AtomicEndpoint == "" {
+		config.AtomicEndpoint = "operations"
+	}
+
 	return &Client{
 		config: config,
 		client: client,
@@ -101,8 +108,56 @@ func (c *Client) Delete(typ, id string) error {
 	return err
 }
 
+// Atomic will perform the specified operations atomically using the atomic
+// operations extension. A nil document is returned if the server responds
+// without results.
+func (c *Client) Atomic(ops ...Operation) (*Document, error) {
+	// prepare document
+	doc := &Document{
+		Operations: make([]*Operation, 0, len(ops)),
+	}
+	for i := range ops {
+		doc.Operations = append(doc.Operations, &ops[i])
+	}
+
+	return c.Do(Request{
+		Intent:       AtomicOperations,
+		ResourceType: c.config.AtomicEndpoint,
+		Extensions:   []string{AtomicExtension},
+	}, doc)
+}
+
+// A Response is the result of a request performed by a client.
+type Response struct {
+	// The status code of the response.
+	Status int
+
+	// The document of the response. It is nil for "Accepted" and "No Content"
+	// responses.
+	Document *Document
+
+	// The extensions and profiles applied by the server as reported by the
+	// content type of the response.
+	Extensions []string
+	Profiles   []string
+}
+
 // Do will perform the specified request and return the result.
 func (c *Client) Do(req Request, doc *Document) (*Document, error) {
+	// perform request
+	res, err := c.Perform(req, doc)
+	if res == nil {
+		return nil, err
+	}
+
+	return res.Document, err
+}
+
+// Perform will perform the specified request and return the response. Unlike
+// Do, the extensions and profiles applied by the server are returned as well.
+// The response is also returned together with the first error of a document
+// that contains errors.
+func (c *Client) Perform(req Request, doc *Document) (*Response, error) {
 	// check doc
 	if req.Intent.DocumentExpected() && doc == nil {
 		return nil, fmt.Errorf("missing document")
@@ -127,9 +182,17 @@ func (c *Client) Do(req Request, doc *Document) (*Document, error) {
 		return nil, err
 	}
 
+	// get media type
+	mediaType := req.MediaType()
+
 	// set content type if body is set
 	if body != nil {
-		r.Header.Set("Content-Type", MediaType)
+		r.Header.Set("Content-Type", mediaType)
+	}
+
+	// request applied extensions and profiles
+	if len(req.Extensions) > 0 || len(req.Profiles) > 0 {
+		r.Header.Set("Accept", mediaType)
 	}
 
 	// authorize request if available
@@ -148,12 +211,25 @@ func (c *Client) Do(req Request, doc *Document) (*Document, error) {
 		_ = res.Body.Close()
 	}()
 
+	// prepare response
+	response := &Response{
+		Status: res.StatusCode,
+	}
+
+	// get applied extensions and profiles
+	_, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err == nil {
+		response.Extensions = splitList(params["ext"])
+		response.Profiles = splitList(params["profile"])
+	}
+
 	// allow other status codes for some requests
 	switch req.Intent {
-	case CreateResource, UpdateResource, DeleteResource:
+	case CreateResource, UpdateResource, DeleteResource, SetRelationship,
+		AppendToRelationship, RemoveFromRelationship, AtomicOperations:
 		switch res.StatusCode {
 		case http.StatusAccepted, http.StatusNoContent:
-			return nil, nil
+			return response, nil
 		}
 	}
 
@@ -161,16 +237,16 @@ func (c *Client) Do(req Request, doc *Document) (*Document, error) {
 	dec := json.NewDecoder(io.LimitReader(res.Body, c.config.ResponseLimit))
 	dec.UseNumber()
 
-	// decode response
-	var response Document
-	err = dec.Decode(&response)
+	// decode document
+	response.Document = &Document{}
+	err = dec.Decode(response.Document)
 	if err != nil {
 		return nil, err
 	}
 
 	// check errors
-	if len(response.Errors) > 0 {
-		return &response, response.Errors[0]
+	if len(response.Document.Errors) > 0 {
+		return response, response.Document.Errors[0]
 	}
 
 	// check status code
@@ -185,5 +261,5 @@ func (c *Client) Do(req Request, doc *Document) (*Document, error) {
 		}
 	}
 
-	return &response, nil
+	return response, nil
 }
@@ -0,0 +1,122 @@
+package jsonapi
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// A Cursor describes a position in a sorted list of resources. It is encoded
+// as an opaque token for the "page[before]" and "page[after]" parameters.
+type Cursor struct {
+	// The sorting the cursor has been created for e.g. Request.Sorting.
+	Sorting []string `json:"s,omitempty"`
+
+	// The sort key values of the resource at the position.
+	//
+	// Note: Numbers are decoded as json.Number values.
+	Values []interface{} `json:"v"`
+}
+
+// Cursor will return a cursor for the position of the passed resource using
+// the sorting of the request. The values are read from the attributes of the
+// resource for each sort field, followed by the resource id as a tiebreaker.
+// The "id" sort field is read from the resource id.
+func (r *Request) Cursor(res *Resource) Cursor {
+	// prepare cursor
+	cursor := Cursor{
+		Sorting: r.Sorting,
+		Values:  make([]interface{}, 0, len(r.Sorting)+1),
+	}
+
+	// add sort values
+	for _, field := range r.SortFields() {
+		cursor.Values = append(cursor.Values, sortValue(res, field.Name))
+	}
+
+	// add id
+	cursor.Values = append(cursor.Values, res.ID)
+
+	return cursor
+}
+
+// CursorCodec encodes and decodes cursors as opaque base64 tokens that are
+// optionally signed using HMAC-SHA256.
+type CursorCodec struct {
+	// The secret used to sign and verify cursors. Cursors are not signed if
+	// the secret is empty.
+	Secret []byte
+}
+
+// Encode will encode the passed cursor.
+func (c *CursorCodec) Encode(cursor Cursor) (string, error) {
+	// encode cursor
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	// encode payload
+	token := base64.RawURLEncoding.EncodeToString(payload)
+
+	// sign payload
+	if len(c.Secret) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(c.sign(payload))
+	}
+
+	return token, nil
+}
+
+// Decode will decode and verify the passed token.
+func (c *CursorCodec) Decode(token string) (*Cursor, error) {
+	// split token
+	parts := strings.SplitN(token, ".", 2)
+	signed := len(parts) == 2
+
+	// check signature presence
+	if signed && len(c.Secret) == 0 {
+		return nil, errors.New("unexpected cursor signature")
+	} else if !signed && len(c.Secret) > 0 {
+		return nil, errors.New("missing cursor signature")
+	}
+
+	// decode payload
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// verify signature
+	if signed {
+		signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if !hmac.Equal(signature, c.sign(payload)) {
+			return nil, errors.New("invalid cursor signature")
+		}
+	}
+
+	// prepare decoder
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	// decode cursor
+	var cursor Cursor
+	err = dec.Decode(&cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
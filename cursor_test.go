@@ -0,0 +1,110 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorCodec(t *testing.T) {
+	cursor := Cursor{
+		Sorting: []string{"-created", "id"},
+		Values:  []interface{}{json.Number("42"), "foo"},
+	}
+
+	codec := &CursorCodec{}
+
+	token, err := codec.Encode(cursor)
+	assert.NoError(t, err)
+	assert.NotContains(t, token, ".")
+
+	decoded, err := codec.Decode(token)
+	assert.NoError(t, err)
+	assert.Equal(t, &cursor, decoded)
+
+	_, err = codec.Decode("!")
+	assert.Error(t, err)
+}
+
+func TestCursorCodecSigned(t *testing.T) {
+	cursor := Cursor{
+		Values: []interface{}{"foo"},
+	}
+
+	codec := &CursorCodec{Secret: []byte("secret")}
+
+	token, err := codec.Encode(cursor)
+	assert.NoError(t, err)
+	assert.Contains(t, token, ".")
+
+	decoded, err := codec.Decode(token)
+	assert.NoError(t, err)
+	assert.Equal(t, &cursor, decoded)
+
+	unsigned, err := (&CursorCodec{}).Encode(cursor)
+	assert.NoError(t, err)
+
+	_, err = codec.Decode(unsigned)
+	assert.Error(t, err)
+
+	_, err = (&CursorCodec{}).Decode(token)
+	assert.Error(t, err)
+
+	_, err = (&CursorCodec{Secret: []byte("other")}).Decode(token)
+	assert.Error(t, err)
+
+	tampered, err := codec.Encode(Cursor{Values: []interface{}{"bar"}})
+	assert.NoError(t, err)
+	_, err = codec.Decode(tampered[:len(tampered)-1] + token[len(token)-1:])
+	assert.Error(t, err)
+}
+
+func TestParseRequestCursors(t *testing.T) {
+	codec := &CursorCodec{Secret: []byte("secret")}
+	parser := &Parser{CursorCodec: codec}
+
+	after, err := codec.Encode(Cursor{
+		Sorting: []string{"title"},
+		Values:  []interface{}{"foo", "1"},
+	})
+	assert.NoError(t, err)
+
+	req, err := parser.ParseRequest(newTestRequest("GET", "posts?sort=title&page[after]="+after))
+	assert.NoError(t, err)
+	assert.Equal(t, after, req.PageAfter)
+	assert.Nil(t, req.CursorBefore)
+	assert.Equal(t, &Cursor{
+		Sorting: []string{"title"},
+		Values:  []interface{}{"foo", "1"},
+	}, req.CursorAfter)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "posts?page[after]="+after))
+	assert.Equal(t, CursorInvalidParameter("cursor sorting mismatch", "page[after]"), err)
+	assert.Nil(t, req)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "posts?sort=title&page[before]=x"+after))
+	assert.Equal(t, CursorInvalidParameter("invalid cursor", "page[before]"), err)
+	assert.Nil(t, req)
+}
+
+func TestRequestCursor(t *testing.T) {
+	req := &Request{Sorting: []string{"-title", "id"}}
+	res := &Resource{
+		Type: "posts",
+		ID:   "1",
+		Attributes: Map{
+			"title": "foo",
+		},
+	}
+
+	assert.Equal(t, Cursor{
+		Sorting: []string{"-title", "id"},
+		Values:  []interface{}{"foo", "1", "1"},
+	}, req.Cursor(res))
+
+	req = &Request{}
+	assert.Equal(t, Cursor{
+		Values: []interface{}{"1"},
+	}, req.Cursor(res))
+}
@@ -4,53 +4,82 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
-// NullLink can be used as a link to encode it as a null value.
-const NullLink = "NULL"
-
-var nullBytes = []byte("null")
-
-// Link is document link that also can be null by setting NullLink.
-type Link string
+// DocumentLinks are a set of links related to a documents primary data.
+//
+// See: http://jsonapi.org/format/#document-links.
+type DocumentLinks struct {
+	Self        *Link `json:"self,omitempty"`
+	Related     *Link `json:"related,omitempty"`
+	DescribedBy *Link `json:"describedby,omitempty"`
+	First       *Link `json:"first,omitempty"`
+	Previous    *Link `json:"prev,omitempty"`
+	Next        *Link `json:"next,omitempty"`
+	Last        *Link `json:"last,omitempty"`
+}
 
-// UnmarshalJSON implements the json.Unmarshaler interface.
-func (l *Link) UnmarshalJSON(data []byte) error {
-	// handle null
-	if bytes.Compare(data, nullBytes) == 0 {
-		*l = NullLink
-		return nil
+// UnmarshalJSON implements the json.Unmarshaler interface. Null links are
+// decoded as links with a NullLink reference.
+func (l *DocumentLinks) UnmarshalJSON(data []byte) error {
+	// decode links
+	var links map[string]*Link
+	err := json.Unmarshal(data, &links)
+	if err != nil {
+		return err
 	}
 
-	// handle string
-	var str string
-	err := json.Unmarshal(data, &str)
-	*l = Link(str)
-
-	return err
-}
-
-// MarshalJSON implements the json.Marshaler interface.
-func (l Link) MarshalJSON() ([]byte, error) {
-	// handle null
-	if l == NullLink {
-		return nullBytes, nil
+	// set links
+	for name, link := range links {
+		// handle null
+		if link == nil {
+			link = &Link{Href: NullLink}
+		}
+
+		// set link
+		switch name {
+		case "self":
+			l.Self = link
+		case "related":
+			l.Related = link
+		case "describedby":
+			l.DescribedBy = link
+		case "first":
+			l.First = link
+		case "prev":
+			l.Previous = link
+		case "next":
+			l.Next = link
+		case "last":
+			l.Last = link
+		}
 	}
 
-	return json.Marshal(string(l))
+	return nil
 }
 
-// DocumentLinks are a set of links related to a documents primary data.
+// JSONAPI describes the implementation of a server and the extensions and
+// profiles applied to a document.
 //
-// See: http://jsonapi.org/format/#document-links.
-type DocumentLinks struct {
-	Self     Link `json:"self,omitempty"`
-	Related  Link `json:"related,omitempty"`
-	First    Link `json:"first,omitempty"`
-	Previous Link `json:"prev,omitempty"`
-	Next     Link `json:"next,omitempty"`
-	Last     Link `json:"last,omitempty"`
+// See: https://jsonapi.org/format/1.1/#document-jsonapi-object.
+type JSONAPI struct {
+	// The highest specification version supported by the server.
+	Version string `json:"version,omitempty"`
+
+	// The URIs of the applied extensions.
+	Ext []string `json:"ext,omitempty"`
+
+	// The URIs of the applied profiles.
+	Profile []string `json:"profile,omitempty"`
+
+	// Non-standard meta-information about the implementation.
+	//
+	// Note: Numbers are left as strings to avoid issues with mismatching types
+	// when they are later assigned to a struct.
+	Meta Map `json:"meta,omitempty"`
 }
 
 // A Document is the root structure of every JSON API response. It is also used
@@ -72,11 +101,20 @@ type Document struct {
 	// A list of errors that occurred during the request.
 	Errors []*Error `json:"errors,omitempty"`
 
+	// A list of operations to be performed atomically. See AtomicExtension.
+	Operations []*Operation `json:"atomic:operations,omitempty"`
+
+	// A list of results of the performed operations. See AtomicExtension.
+	Results []*Result `json:"atomic:results,omitempty"`
+
 	// Non-standard meta-information about the document.
 	//
 	// Note: Numbers are left as strings to avoid issues with mismatching types
 	// when they are later assigned to a struct.
 	Meta Map `json:"meta,omitempty"`
+
+	// Information about the implementation of the server.
+	JSONAPI *JSONAPI `json:"jsonapi,omitempty"`
 }
 
 // ParseDocument will decode a JSON API document from the passed reader.
@@ -84,7 +122,33 @@ type Document struct {
 // Note: If the read document contains errors the first Error will be returned
 // as an error.
 func ParseDocument(r io.Reader) (*Document, error) {
-	// TODO: Check document validity more in depth?
+	return (&Parser{}).ParseDocument(r)
+}
+
+// ParseDocument will decode a JSON API document from the passed reader. If
+// StrictDocuments is enabled, the structure of the document is validated using
+// ValidateDocument and its linkage using Document.Validate. The first
+// validation error is returned in that case.
+//
+// Note: If the read document contains errors the first Error will be returned
+// as an error.
+func (p *Parser) ParseDocument(r io.Reader) (*Document, error) {
+	// validate structure
+	if p.StrictDocuments {
+		// read document
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, BadRequest(err.Error())
+		}
+
+		// validate document
+		if errs := ValidateDocument(data); len(errs) > 0 {
+			return nil, errs[0]
+		}
+
+		// replace reader
+		r = bytes.NewReader(data)
+	}
 
 	// prepare document
 	var doc Document
@@ -104,14 +168,33 @@ func ParseDocument(r io.Reader) (*Document, error) {
 		return nil, doc.Errors[0]
 	}
 
+	// validate linkage
+	if p.StrictDocuments {
+		if errs := doc.Validate(); len(errs) > 0 {
+			return nil, errs[0]
+		}
+	}
+
 	return &doc, nil
 }
 
 // WriteResponse will write the status and supplied document to the passed
-// response writer.
+// response writer. A JSON API content type that has already been set e.g.
+// using Request.MediaType is kept to echo the applied extensions and profiles.
+// Otherwise, the content type is derived from the extensions and profiles of
+// the JSONAPI object.
 func WriteResponse(w http.ResponseWriter, status int, doc *Document) error {
-	// set content type
-	w.Header().Set("Content-Type", MediaType)
+	// set content type if missing
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), MediaType) {
+		if doc != nil && doc.JSONAPI != nil {
+			w.Header().Set("Content-Type", (&Request{
+				Extensions: doc.JSONAPI.Ext,
+				Profiles:   doc.JSONAPI.Profile,
+			}).MediaType())
+		} else {
+			w.Header().Set("Content-Type", MediaType)
+		}
+	}
 
 	// write status
 	w.WriteHeader(status)
@@ -68,7 +68,7 @@ func TestParseDocument(t *testing.T) {
 				Type:          "foo",
 				ID:            "1",
 				Attributes:    make(map[string]interface{}),
-				Relationships: make(map[string]*Document),
+				Relationships: make(map[string]*Relationship),
 			},
 		},
 	}, doc)
@@ -93,7 +93,7 @@ func TestParseDocumentWithManyResources(t *testing.T) {
 					Type:          "foo",
 					ID:            "1",
 					Attributes:    make(map[string]interface{}),
-					Relationships: make(map[string]*Document),
+					Relationships: make(map[string]*Relationship),
 				},
 			},
 		},
@@ -120,7 +120,7 @@ func TestParseDocumentDocumentWithRelationships(t *testing.T) {
 			One: &Resource{
 				Type: "foo",
 				ID:   "1",
-				Relationships: map[string]*Document{
+				Relationships: map[string]*Relationship{
 					"bar": {
 						Data: &HybridResource{
 							One: &Resource{
@@ -158,7 +158,7 @@ func TestParseDocumentWithBigNumbers(t *testing.T) {
 				Attributes: map[string]interface{}{
 					"num": json.Number("4699539"),
 				},
-				Relationships: map[string]*Document{},
+				Relationships: map[string]*Relationship{},
 			},
 		},
 	}, doc)
@@ -177,11 +177,29 @@ func TestParseDocumentNullLink(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, &Document{
 		Links: &DocumentLinks{
-			Self: NullLink,
+			Self: &Link{Href: NullLink},
 		},
 	}, doc)
 }
 
+func TestParseDocumentErrorNullLink(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(`{
+  		"errors": [{
+			"links": {
+				"about": null,
+				"type": "https://example.com/errors/foo"
+			}
+		}]
+	}`))
+	assert.Nil(t, doc)
+	assert.Equal(t, &Error{
+		Links: &ErrorLinks{
+			About: &Link{Href: NullLink},
+			Type:  &Link{Href: "https://example.com/errors/foo"},
+		},
+	}, err)
+}
+
 func TestWriteResponseOneResource(t *testing.T) {
 	res := httptest.NewRecorder()
 
@@ -243,7 +261,7 @@ func TestWriteResponseNullLink(t *testing.T) {
 
 	err := WriteResponse(res, http.StatusOK, &Document{
 		Links: &DocumentLinks{
-			Self: NullLink,
+			Self: &Link{Href: NullLink},
 		},
 	})
 	assert.NoError(t, err)
@@ -254,6 +272,52 @@ func TestWriteResponseNullLink(t *testing.T) {
 	}`, res.Body.String())
 }
 
+func TestParseDocumentJSONAPI(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(`{
+		"jsonapi": {
+			"version": "1.1",
+			"ext": ["https://jsonapi.org/ext/atomic"],
+			"profile": ["https://example.com/profile"],
+			"meta": {
+				"foo": "bar"
+			}
+		},
+		"meta": {}
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &Document{
+		Meta: Map{},
+		JSONAPI: &JSONAPI{
+			Version: "1.1",
+			Ext:     []string{AtomicExtension},
+			Profile: []string{"https://example.com/profile"},
+			Meta: Map{
+				"foo": "bar",
+			},
+		},
+	}, doc)
+}
+
+func TestWriteResponseJSONAPI(t *testing.T) {
+	res := httptest.NewRecorder()
+	err := WriteResponse(res, http.StatusOK, &Document{
+		Data: &HybridResource{},
+		JSONAPI: &JSONAPI{
+			Version: "1.1",
+			Profile: []string{"https://example.com/profile"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `application/vnd.api+json; profile="https://example.com/profile"`, res.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"data": null,
+		"jsonapi": {
+			"version": "1.1",
+			"profile": ["https://example.com/profile"]
+		}
+	}`, res.Body.String())
+}
+
 func BenchmarkParseDocument(b *testing.B) {
 	reader := strings.NewReader(`{
 		"links": {
@@ -287,7 +351,7 @@ func BenchmarkParseDocument(b *testing.B) {
 func BenchmarkWriteResponse(b *testing.B) {
 	doc := &Document{
 		Links: &DocumentLinks{
-			Self: "/api/foo/1",
+			Self: &Link{Href: "/api/foo/1"},
 		},
 		Data: &HybridResource{
 			One: &Resource{
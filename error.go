@@ -1,6 +1,7 @@
 package jsonapi
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -45,7 +46,40 @@ type Error struct {
 type ErrorLinks struct {
 	// A link that leads to further details about this particular occurrence of
 	// the problem.
-	About string `json:"about"`
+	About *Link `json:"about,omitempty"`
+
+	// A link that identifies the type of error that this particular error is
+	// an instance of.
+	Type *Link `json:"type,omitempty"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Null links are
+// decoded as links with a NullLink reference.
+func (l *ErrorLinks) UnmarshalJSON(data []byte) error {
+	// decode links
+	var links map[string]*Link
+	err := json.Unmarshal(data, &links)
+	if err != nil {
+		return err
+	}
+
+	// set links
+	for name, link := range links {
+		// handle null
+		if link == nil {
+			link = &Link{Href: NullLink}
+		}
+
+		// set link
+		switch name {
+		case "about":
+			l.About = link
+		case "type":
+			l.Type = link
+		}
+	}
+
+	return nil
 }
 
 // ErrorSource contains a parameter or pointer reference to the source of the
@@ -184,6 +218,58 @@ func BadRequestPointer(detail, pointer string) *Error {
 	return err
 }
 
+// CursorMaxSizeExceeded returns a new bad request error for a requested page
+// size that exceeds the maximum page size as defined by the cursor pagination
+// profile.
+func CursorMaxSizeExceeded(maxSize int64) *Error {
+	err := BadRequestParam("page size exceeds maximum", "page[size]")
+	err.Links = &ErrorLinks{
+		Type: &Link{Href: CursorPaginationProfile + "/max-size-exceeded"},
+	}
+	err.Meta = Map{
+		"page": Map{
+			"maxSize": maxSize,
+		},
+	}
+
+	return err
+}
+
+// CursorInvalidParameter returns a new bad request error for an invalid
+// pagination parameter as defined by the cursor pagination profile.
+func CursorInvalidParameter(detail, param string) *Error {
+	err := BadRequestParam(detail, param)
+	err.Links = &ErrorLinks{
+		Type: &Link{Href: CursorPaginationProfile + "/invalid-parameter"},
+	}
+
+	return err
+}
+
+// CursorRangePaginationNotSupported returns a new bad request error for
+// requests that use both the "page[before]" and "page[after]" parameter as
+// defined by the cursor pagination profile.
+func CursorRangePaginationNotSupported() *Error {
+	err := BadRequest("range pagination not supported")
+	err.Links = &ErrorLinks{
+		Type: &Link{Href: CursorPaginationProfile + "/range-pagination-not-supported"},
+	}
+
+	return err
+}
+
+// CursorUnsupportedSort returns a new bad request error for a requested
+// sorting that cannot be used for pagination as defined by the cursor
+// pagination profile.
+func CursorUnsupportedSort(detail string) *Error {
+	err := BadRequestParam(detail, "sort")
+	err.Links = &ErrorLinks{
+		Type: &Link{Href: CursorPaginationProfile + "/unsupported-sort"},
+	}
+
+	return err
+}
+
 // InternalServerError returns na new internal server error.
 func InternalServerError(detail string) *Error {
 	return ErrorFromStatus(http.StatusInternalServerError, detail)
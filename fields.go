@@ -0,0 +1,108 @@
+package jsonapi
+
+import "net/http"
+
+// ApplyFields will remove the attributes and relationships of the primary data
+// and included resources that are not listed in the sparse fieldset of their
+// type. Resources without a fieldset and the identifiers of all resources are
+// kept. Filtered resources are replaced by copies to leave the passed
+// resources untouched.
+func (d *Document) ApplyFields(fields map[string][]string) {
+	// check fields
+	if len(fields) == 0 {
+		return
+	}
+
+	// apply primary data
+	if d.Data != nil {
+		if d.Data.One != nil {
+			d.Data.One = applyFields(d.Data.One, fields)
+		}
+		if d.Data.Many != nil {
+			list := make([]*Resource, 0, len(d.Data.Many))
+			for _, res := range d.Data.Many {
+				list = append(list, applyFields(res, fields))
+			}
+			d.Data.Many = list
+		}
+	}
+
+	// apply included resources
+	if d.Included != nil {
+		list := make([]*Resource, 0, len(d.Included))
+		for _, res := range d.Included {
+			list = append(list, applyFields(res, fields))
+		}
+		d.Included = list
+	}
+}
+
+func applyFields(res *Resource, fields map[string][]string) *Resource {
+	// check resource
+	if res == nil {
+		return nil
+	}
+
+	// get fieldset
+	fieldset, ok := fields[res.Type]
+	if !ok {
+		return res
+	}
+
+	// copy resource
+	res = copyResource(res)
+
+	// remove attributes
+	for name := range res.Attributes {
+		if !contains(fieldset, name) {
+			delete(res.Attributes, name)
+		}
+	}
+
+	// remove relationships
+	for name := range res.Relationships {
+		if !contains(fieldset, name) {
+			delete(res.Relationships, name)
+		}
+	}
+
+	return res
+}
+
+// WriteResource will write the passed resource like WriteResource and apply
+// the sparse fieldsets of the request.
+func (r *Request) WriteResource(w http.ResponseWriter, status int, resource *Resource, links *DocumentLinks, included ...*Resource) error {
+	return r.write(w, status, &Document{
+		Data: &HybridResource{
+			One: resource,
+		},
+		Links:    links,
+		Included: included,
+	})
+}
+
+// WriteResources will write the passed resources like WriteResources and apply
+// the sparse fieldsets of the request.
+func (r *Request) WriteResources(w http.ResponseWriter, status int, resources []*Resource, links *DocumentLinks, included ...*Resource) error {
+	return r.write(w, status, &Document{
+		Data: &HybridResource{
+			Many: resources,
+		},
+		Links:    links,
+		Included: included,
+	})
+}
+
+func (r *Request) write(w http.ResponseWriter, status int, doc *Document) error {
+	// apply sparse fieldsets
+	doc.ApplyFields(r.Fields)
+
+	// add jsonapi object
+	if doc.JSONAPI == nil && r.jsonapi != nil {
+		cpy := *doc
+		cpy.JSONAPI = r.jsonapi
+		doc = &cpy
+	}
+
+	return WriteResponse(w, status, doc)
+}
@@ -0,0 +1,152 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentApplyFields(t *testing.T) {
+	post := &Resource{
+		Type: "posts",
+		ID:   "1",
+		Attributes: Map{
+			"title": "foo",
+			"body":  "bar",
+		},
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{One: &Resource{Type: "users", ID: "2"}},
+			},
+			"comments": {
+				Data: &HybridResource{Many: []*Resource{}},
+			},
+		},
+	}
+
+	user := &Resource{
+		Type: "users",
+		ID:   "2",
+		Attributes: Map{
+			"name": "baz",
+		},
+	}
+
+	comment := &Resource{
+		Type: "comments",
+		ID:   "3",
+		Attributes: Map{
+			"text": "qux",
+		},
+	}
+
+	doc := &Document{
+		Data:     &HybridResource{Many: []*Resource{post}},
+		Included: []*Resource{user, comment},
+	}
+	doc.ApplyFields(map[string][]string{
+		"posts": {"title", "author"},
+		"users": {""},
+	})
+
+	assert.Equal(t, &Document{
+		Data: &HybridResource{
+			Many: []*Resource{
+				{
+					Type: "posts",
+					ID:   "1",
+					Attributes: Map{
+						"title": "foo",
+					},
+					Relationships: map[string]*Relationship{
+						"author": {
+							Data: &HybridResource{One: &Resource{Type: "users", ID: "2"}},
+						},
+					},
+				},
+			},
+		},
+		Included: []*Resource{
+			{
+				Type:       "users",
+				ID:         "2",
+				Attributes: Map{},
+			},
+			comment,
+		},
+	}, doc)
+
+	assert.Len(t, post.Attributes, 2)
+	assert.Len(t, post.Relationships, 2)
+	assert.Len(t, user.Attributes, 1)
+
+	doc = &Document{
+		Data: &HybridResource{One: post},
+	}
+	doc.ApplyFields(nil)
+	assert.Equal(t, post, doc.Data.One)
+}
+
+func TestRequestWriteResource(t *testing.T) {
+	req := &Request{
+		Fields: map[string][]string{
+			"foo": {"bar"},
+		},
+	}
+
+	res := httptest.NewRecorder()
+
+	err := req.WriteResource(res, http.StatusOK, &Resource{
+		Type: "foo",
+		ID:   "1",
+		Attributes: Map{
+			"foo": "bar",
+			"bar": "baz",
+		},
+	}, nil, &Resource{
+		Type: "foo",
+		ID:   "2",
+		Attributes: Map{
+			"foo": "bar",
+		},
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"data": {
+			"type": "foo",
+			"id": "1",
+			"attributes": {
+				"bar": "baz"
+			}
+		},
+		"included": [
+			{
+				"type": "foo",
+				"id": "2"
+			}
+		]
+	}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+
+	err = req.WriteResources(res, http.StatusOK, []*Resource{
+		{
+			Type: "foo",
+			ID:   "1",
+			Attributes: Map{
+				"foo": "bar",
+			},
+		},
+	}, nil)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"data": [
+			{
+				"type": "foo",
+				"id": "1"
+			}
+		]
+	}`, res.Body.String())
+}
@@ -0,0 +1,145 @@
+package jsonapi
+
+import (
+	"sort"
+	"strings"
+)
+
+// A FilterOperator describes how a filter condition compares a field.
+type FilterOperator string
+
+// The available filter operators.
+const (
+	EqualOperator              FilterOperator = "eq"
+	NotEqualOperator           FilterOperator = "ne"
+	GreaterThanOperator        FilterOperator = "gt"
+	GreaterThanOrEqualOperator FilterOperator = "gte"
+	LessThanOperator           FilterOperator = "lt"
+	LessThanOrEqualOperator    FilterOperator = "lte"
+	LikeOperator               FilterOperator = "like"
+	InOperator                 FilterOperator = "in"
+)
+
+// Valid returns whether the operator is valid.
+func (o FilterOperator) Valid() bool {
+	switch o {
+	case EqualOperator, NotEqualOperator, GreaterThanOperator,
+		GreaterThanOrEqualOperator, LessThanOperator, LessThanOrEqualOperator,
+		LikeOperator, InOperator:
+		return true
+	}
+
+	return false
+}
+
+// A Condition is a single filter condition parsed from a "filter" query
+// parameter. The following forms are supported:
+//
+//	filter[title]=foo          title eq "foo"
+//	filter[status]=a,b         status in ("a", "b")
+//	filter[age][gt]=30         age gt "30"
+//	filter[name][like]=foo*    name like "foo*"
+//	filter[author.name]=foo    author.name eq "foo"
+//
+// Note: Values are not converted and kept as strings.
+type Condition struct {
+	// The path of the filtered field e.g. ["author", "name"].
+	Path []string
+
+	// The operator used to compare the field.
+	Operator FilterOperator
+
+	// The compared values. Only the "in" operator has more than one value.
+	Values []string
+}
+
+// Field returns the dotted path of the filtered field e.g. "author.name".
+func (c Condition) Field() string {
+	return strings.Join(c.Path, ".")
+}
+
+// Param returns the query parameter name of the condition e.g.
+// "filter[age][gt]". The operator is omitted for equality conditions.
+func (c Condition) Param() string {
+	// handle equality
+	if c.Operator == EqualOperator || c.Operator == "" {
+		return "filter[" + c.Field() + "]"
+	}
+
+	return "filter[" + c.Field() + "][" + string(c.Operator) + "]"
+}
+
+// Value returns the query parameter value of the condition.
+//
+// Note: Values of the "eq" operator that contain a comma will be parsed as an
+// "in" condition.
+func (c Condition) Value() string {
+	return strings.Join(c.Values, ",")
+}
+
+// ParseCondition will parse a condition from the passed filter query parameter
+// name and value. Any returned error can directly be written using WriteError.
+func ParseCondition(param, value string) (*Condition, error) {
+	// check format
+	if !strings.HasPrefix(param, "filter[") || !strings.HasSuffix(param, "]") {
+		return nil, BadRequestParam("invalid filter parameter", param)
+	}
+
+	// split key
+	parts := strings.Split(param[7:len(param)-1], "][")
+	if len(parts) > 2 {
+		return nil, BadRequestParam("invalid filter parameter", param)
+	}
+
+	// check brackets
+	for _, part := range parts {
+		if strings.ContainsAny(part, "[]") {
+			return nil, BadRequestParam("invalid filter parameter", param)
+		}
+	}
+
+	// parse path
+	path := strings.Split(parts[0], ".")
+	for _, segment := range path {
+		if segment == "" {
+			return nil, BadRequestParam("invalid filter path", param)
+		}
+	}
+
+	// get operator
+	operator := EqualOperator
+	if len(parts) == 2 {
+		operator = FilterOperator(parts[1])
+		if !operator.Valid() {
+			return nil, BadRequestParam("unsupported filter operator", param)
+		}
+	}
+
+	// prepare condition
+	cond := &Condition{
+		Path:     path,
+		Operator: operator,
+	}
+
+	// set values
+	switch operator {
+	case EqualOperator, InOperator:
+		cond.Values = strings.Split(value, ",")
+		if len(cond.Values) > 1 {
+			cond.Operator = InOperator
+		}
+	default:
+		if value == "" {
+			return nil, BadRequestParam("missing filter value", param)
+		}
+		cond.Values = []string{value}
+	}
+
+	return cond, nil
+}
+
+func sortConditions(list []Condition) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Param() < list[j].Param()
+	})
+}
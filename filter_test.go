@@ -0,0 +1,169 @@
+package jsonapi
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCondition(t *testing.T) {
+	list := []struct {
+		p string
+		v string
+		c *Condition
+		e string
+	}{
+		{
+			p: "filter[title]",
+			v: "foo",
+			c: &Condition{Path: []string{"title"}, Operator: EqualOperator, Values: []string{"foo"}},
+		},
+		{
+			p: "filter[status]",
+			v: "a,b",
+			c: &Condition{Path: []string{"status"}, Operator: InOperator, Values: []string{"a", "b"}},
+		},
+		{
+			p: "filter[status][in]",
+			v: "a",
+			c: &Condition{Path: []string{"status"}, Operator: InOperator, Values: []string{"a"}},
+		},
+		{
+			p: "filter[age][gt]",
+			v: "30",
+			c: &Condition{Path: []string{"age"}, Operator: GreaterThanOperator, Values: []string{"30"}},
+		},
+		{
+			p: "filter[name][like]",
+			v: "foo*,bar",
+			c: &Condition{Path: []string{"name"}, Operator: LikeOperator, Values: []string{"foo*,bar"}},
+		},
+		{
+			p: "filter[author.name]",
+			v: "foo",
+			c: &Condition{Path: []string{"author", "name"}, Operator: EqualOperator, Values: []string{"foo"}},
+		},
+		{
+			p: "filter[age][gt][lt]",
+			v: "1",
+			e: "invalid filter parameter",
+		},
+		{
+			p: "filter[a]b]",
+			v: "foo",
+			e: "invalid filter parameter",
+		},
+		{
+			p: "filter[a][gt]b]",
+			v: "1",
+			e: "invalid filter parameter",
+		},
+		{
+			p: "filter[author..name]",
+			v: "foo",
+			e: "invalid filter path",
+		},
+		{
+			p: "filter[]",
+			v: "foo",
+			e: "invalid filter path",
+		},
+		{
+			p: "filter[age][foo]",
+			v: "1",
+			e: "unsupported filter operator",
+		},
+		{
+			p: "filter[age][lte]",
+			v: "",
+			e: "missing filter value",
+		},
+	}
+
+	for _, item := range list {
+		cond, err := ParseCondition(item.p, item.v)
+		if item.e != "" {
+			assert.Equal(t, BadRequestParam(item.e, item.p), err, item.p)
+			assert.Nil(t, cond)
+		} else {
+			assert.NoError(t, err, item.p)
+			assert.Equal(t, item.c, cond, item.p)
+		}
+	}
+}
+
+func TestConditionRoundTrip(t *testing.T) {
+	list := []Condition{
+		{Path: []string{"title"}, Operator: EqualOperator, Values: []string{"foo"}},
+		{Path: []string{"title"}, Operator: NotEqualOperator, Values: []string{"foo"}},
+		{Path: []string{"age"}, Operator: GreaterThanOperator, Values: []string{"30"}},
+		{Path: []string{"age"}, Operator: GreaterThanOrEqualOperator, Values: []string{"30"}},
+		{Path: []string{"age"}, Operator: LessThanOperator, Values: []string{"30"}},
+		{Path: []string{"age"}, Operator: LessThanOrEqualOperator, Values: []string{"30"}},
+		{Path: []string{"author", "name"}, Operator: LikeOperator, Values: []string{"foo*"}},
+		{Path: []string{"status"}, Operator: InOperator, Values: []string{"a"}},
+		{Path: []string{"status"}, Operator: InOperator, Values: []string{"a", "b"}},
+	}
+
+	for _, cond := range list {
+		if cond.Operator != EqualOperator {
+			assert.Equal(t, "filter["+cond.Field()+"]["+string(cond.Operator)+"]", cond.Param())
+		}
+
+		parsed, err := ParseCondition(cond.Param(), cond.Value())
+		assert.NoError(t, err, cond.Param())
+		assert.Equal(t, &cond, parsed, cond.Param())
+	}
+}
+
+func TestParseRequestConditions(t *testing.T) {
+	parser := &Parser{FilterConditions: true}
+
+	req, err := parser.ParseRequest(newTestRequest("GET", "foo?filter[status]=a,b&filter[age][gt]=30&filter[age][lt]=50&filter[author.name][like]=foo*"))
+	assert.NoError(t, err)
+	assert.Equal(t, &Request{
+		Intent:       ListResources,
+		ResourceType: "foo",
+		Conditions: []Condition{
+			{Path: []string{"age"}, Operator: GreaterThanOperator, Values: []string{"30"}},
+			{Path: []string{"age"}, Operator: LessThanOperator, Values: []string{"50"}},
+			{Path: []string{"author", "name"}, Operator: LikeOperator, Values: []string{"foo*"}},
+			{Path: []string{"status"}, Operator: InOperator, Values: []string{"a", "b"}},
+		},
+	}, req)
+
+	assert.Equal(t, url.Values{
+		"filter[age][gt]":           []string{"30"},
+		"filter[age][lt]":           []string{"50"},
+		"filter[author.name][like]": []string{"foo*"},
+		"filter[status][in]":        []string{"a,b"},
+	}, req.Query())
+
+	r := newTestRequest("GET", "foo")
+	r.URL.RawQuery = req.Query().Encode()
+	req2, err := parser.ParseRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, req, req2)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "foo?filter[age][foo]=1"))
+	assert.Equal(t, BadRequestParam("unsupported filter operator", "filter[age][foo]"), err)
+	assert.Nil(t, req)
+}
+
+func TestRequestMergeConditions(t *testing.T) {
+	req := Request{
+		Conditions: []Condition{
+			{Path: []string{"age"}, Operator: GreaterThanOperator, Values: []string{"30"}},
+		},
+	}.Merge(Request{
+		Conditions: []Condition{
+			{Path: []string{"status"}, Operator: EqualOperator, Values: []string{"a"}},
+		},
+	})
+
+	assert.Equal(t, []Condition{
+		{Path: []string{"age"}, Operator: GreaterThanOperator, Values: []string{"30"}},
+		{Path: []string{"status"}, Operator: EqualOperator, Values: []string{"a"}},
+	}, req.Conditions)
+}
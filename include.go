@@ -0,0 +1,129 @@
+package jsonapi
+
+import "strings"
+
+// An IncludeNode is a node in the tree of included relationships. The root
+// node of a tree has no name.
+type IncludeNode struct {
+	// The name of the relationship.
+	Name string
+
+	// The included relationships of the related resources.
+	Children []*IncludeNode
+}
+
+// ParseIncludeTree will parse a tree from the passed include paths e.g.
+// "posts.author.company". Empty paths are ignored.
+func ParseIncludeTree(paths []string) *IncludeNode {
+	// prepare root
+	root := &IncludeNode{}
+
+	// add paths
+	for _, path := range paths {
+		root.Add(path)
+	}
+
+	return root
+}
+
+// Add will add the passed path to the tree.
+func (n *IncludeNode) Add(path string) {
+	// check path
+	if path == "" {
+		return
+	}
+
+	// add segments
+	node := n
+	for _, name := range strings.Split(path, ".") {
+		child := node.Child(name)
+		if child == nil {
+			child = &IncludeNode{Name: name}
+			node.Children = append(node.Children, child)
+		}
+		node = child
+	}
+}
+
+// Child will return the child with the passed name or nil if missing.
+func (n *IncludeNode) Child(name string) *IncludeNode {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// Includes will return whether the passed path is included. A path is also
+// included if one of its children is included.
+func (n *IncludeNode) Includes(path string) bool {
+	// walk segments
+	node := n
+	for _, name := range strings.Split(path, ".") {
+		node = node.Child(name)
+		if node == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Fetches will return all included paths with parents before their children
+// e.g. "posts", "posts.author" and "posts.author.company". These are the
+// relationships that need to be loaded to build the included resources.
+func (n *IncludeNode) Fetches() []string {
+	var list []string
+	n.walk("", func(path string, _ *IncludeNode) {
+		list = append(list, path)
+	})
+
+	return list
+}
+
+// Paths will return the minimal list of paths that describe the tree e.g.
+// "posts.author.company".
+func (n *IncludeNode) Paths() []string {
+	var list []string
+	n.walk("", func(path string, node *IncludeNode) {
+		if len(node.Children) == 0 {
+			list = append(list, path)
+		}
+	})
+
+	return list
+}
+
+func (n *IncludeNode) walk(prefix string, fn func(string, *IncludeNode)) {
+	for _, child := range n.Children {
+		// get path
+		path := child.Name
+		if prefix != "" {
+			path = prefix + "." + child.Name
+		}
+
+		// yield child
+		fn(path, child)
+
+		// walk child
+		child.walk(path, fn)
+	}
+}
+
+// IncludeTree will return the tree of included relationships of the request.
+func (r *Request) IncludeTree() *IncludeNode {
+	return ParseIncludeTree(r.Include)
+}
+
+// Includes will return whether the passed path is included by the request.
+func (r *Request) Includes(path string) bool {
+	return r.IncludeTree().Includes(path)
+}
+
+// SetIncludeTree will set the included resources of the request to the paths
+// of the passed tree.
+func (r *Request) SetIncludeTree(tree *IncludeNode) {
+	r.Include = tree.Paths()
+}
@@ -0,0 +1,67 @@
+package jsonapi
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncludeTree(t *testing.T) {
+	tree := ParseIncludeTree([]string{"posts.author.company", "posts", "", "comments", "posts.tags"})
+	assert.Equal(t, &IncludeNode{
+		Children: []*IncludeNode{
+			{
+				Name: "posts",
+				Children: []*IncludeNode{
+					{
+						Name: "author",
+						Children: []*IncludeNode{
+							{Name: "company"},
+						},
+					},
+					{Name: "tags"},
+				},
+			},
+			{Name: "comments"},
+		},
+	}, tree)
+
+	assert.True(t, tree.Includes("posts"))
+	assert.True(t, tree.Includes("posts.author"))
+	assert.True(t, tree.Includes("posts.author.company"))
+	assert.False(t, tree.Includes("author"))
+	assert.False(t, tree.Includes("posts.comments"))
+
+	assert.Equal(t, []string{
+		"posts",
+		"posts.author",
+		"posts.author.company",
+		"posts.tags",
+		"comments",
+	}, tree.Fetches())
+
+	assert.Equal(t, []string{
+		"posts.author.company",
+		"posts.tags",
+		"comments",
+	}, tree.Paths())
+
+	assert.Nil(t, ParseIncludeTree(nil).Paths())
+}
+
+func TestRequestIncludeTree(t *testing.T) {
+	req := &Request{
+		Include: []string{"author", "author.company"},
+	}
+	assert.True(t, req.Includes("author"))
+	assert.False(t, req.Includes("comments"))
+
+	tree := req.IncludeTree()
+	tree.Add("comments")
+	req.SetIncludeTree(tree)
+	assert.Equal(t, []string{"author.company", "comments"}, req.Include)
+	assert.Equal(t, url.Values{
+		"include": []string{"author.company,comments"},
+	}, req.Query())
+}
@@ -6,6 +6,7 @@ package jsonapi
 import (
 	"bytes"
 	"encoding/json"
+	"reflect"
 )
 
 // MediaType is the official JSON API media type that should be used by
@@ -27,23 +28,29 @@ type Map map[string]interface{}
 // Note: Numbers are left as strings to avoid issues with mismatching types
 // when they are later assigned to a struct again.
 //
-// Warning: The function does actually convert the struct to json and then
-// convert that json to a map. High performance applications might want to use
-// a custom implementation that is much faster.
+// The fields of structs are copied using a cached per-type field plan that
+// follows the same rules as "encoding/json". Only values that implement custom
+// marshalling or are not of a basic type are converted using "encoding/json".
+// Sources that are not structs or pointers to structs or that implement
+// json.Marshaler or encoding.TextMarshaler are always converted using
+// "encoding/json".
 func StructToMap(source interface{}, fields []string) (Map, error) {
-	// marshal struct as json
-	buf, err := json.Marshal(source)
-	if err != nil {
-		return nil, err
+	// get value
+	value := reflect.ValueOf(source)
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
 	}
 
-	// prepare decoder
-	dec := json.NewDecoder(bytes.NewReader(buf))
-	dec.UseNumber()
-
-	// unmarshal json to map
+	// prepare map
 	var m Map
-	err = dec.Decode(&m)
+	var err error
+
+	// convert struct directly or use json
+	if value.Kind() == reflect.Struct && !hasCustomMarshaller(value.Type(), jsonMarshalerType, textMarshalerType) {
+		m, err = getFieldPlan(value.Type()).toMap(value)
+	} else {
+		m, err = structToMapJSON(source)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -76,10 +83,49 @@ func StructToMap(source interface{}, fields []string) (Map, error) {
 //
 // Note: The "json" tag will be respected to match field names.
 //
-// Warning: The function does actually convert the map to json and then assign
-// that json to the struct. High performance applications might want to use a
-// custom implementation that is much faster.
+// Values are assigned using a cached per-type field plan that follows the same
+// rules as "encoding/json". Targets that are not pointers to structs or that
+// implement json.Unmarshaler or encoding.TextUnmarshaler are always assigned
+// using "encoding/json".
 func (m Map) Assign(target interface{}) error {
+	// get value
+	value := reflect.ValueOf(target)
+
+	// assign struct directly or use json
+	if value.Kind() == reflect.Ptr && !value.IsNil() && value.Elem().Kind() == reflect.Struct &&
+		!hasCustomMarshaller(value.Elem().Type(), jsonUnmarshalerType, textUnmarshalerType) {
+		return getFieldPlan(value.Elem().Type()).assign(value.Elem(), m)
+	}
+
+	return m.assignJSON(target)
+}
+
+// structToMapJSON will convert the source to json and then convert that json
+// to a map.
+func structToMapJSON(source interface{}) (Map, error) {
+	// marshal struct as json
+	buf, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare decoder
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+
+	// unmarshal json to map
+	var m Map
+	err = dec.Decode(&m)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// assignJSON will convert the map to json and then assign that json to the
+// target.
+func (m Map) assignJSON(target interface{}) error {
 	// marshal map to json
 	buf, err := json.Marshal(m)
 	if err != nil {
@@ -1,9 +1,11 @@
 package jsonapi
 
 import (
+	"encoding/json"
 	"math"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -190,6 +192,295 @@ func TestBigNumberConversion(t *testing.T) {
 	assert.True(t, reflect.DeepEqual(i, ii))
 }
 
+type testEmbedded struct {
+	Embedded string `json:"embedded"`
+	Shadowed string `json:"shadowed"`
+}
+
+type testPlan struct {
+	testEmbedded
+	*TestPointer
+	String   string            `json:"string"`
+	Bool     bool              `json:"bool"`
+	Int      int               `json:"int"`
+	Uint16   uint16            `json:"uint16"`
+	Float32  float32           `json:"float32"`
+	Float64  float64           `json:"float64"`
+	Small    float64           `json:"small"`
+	Quoted   int64             `json:"quoted,string"`
+	Omitted  string            `json:"omitted,omitempty"`
+	Shadowed int               `json:"shadowed"`
+	Time     time.Time         `json:"time"`
+	Pointer  *string           `json:"pointer"`
+	Slice    []int             `json:"slice"`
+	Map      map[string]string `json:"map"`
+	Nested   struct {
+		Foo string `json:"foo"`
+	} `json:"nested"`
+	Ignored  string `json:"-"`
+	Untagged string
+	private  string
+}
+
+// TestPointer is exported to allow "encoding/json" to allocate it.
+type TestPointer struct {
+	Pointed string `json:"pointed"`
+}
+
+func newTestPlan() *testPlan {
+	str := "foo"
+	test := &testPlan{
+		testEmbedded: testEmbedded{
+			Embedded: "embedded",
+			Shadowed: "shadowed",
+		},
+		TestPointer: &TestPointer{
+			Pointed: "pointed",
+		},
+		String:   "string",
+		Bool:     true,
+		Int:      -42,
+		Uint16:   42,
+		Float32:  3.14,
+		Float64:  1e21,
+		Small:    1e-7,
+		Quoted:   7,
+		Shadowed: 5,
+		Time:     time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Pointer:  &str,
+		Slice:    []int{1, 2},
+		Map:      map[string]string{"foo": "bar"},
+		Ignored:  "ignored",
+		Untagged: "untagged",
+		private:  "private",
+	}
+	test.Nested.Foo = "bar"
+	return test
+}
+
+func TestStructToMapPlan(t *testing.T) {
+	test := newTestPlan()
+
+	m1, err := StructToMap(test, nil)
+	assert.NoError(t, err)
+
+	m2, err := structToMapJSON(test)
+	assert.NoError(t, err)
+
+	assert.Equal(t, m2, m1)
+	assert.Equal(t, Map{
+		"embedded": "embedded",
+		"pointed":  "pointed",
+		"string":   "string",
+		"bool":     true,
+		"int":      json.Number("-42"),
+		"uint16":   json.Number("42"),
+		"float32":  json.Number("3.14"),
+		"float64":  json.Number("1e+21"),
+		"small":    json.Number("1e-7"),
+		"quoted":   "7",
+		"shadowed": json.Number("5"),
+		"time":     "2020-01-02T03:04:05Z",
+		"pointer":  "foo",
+		"slice":    []interface{}{json.Number("1"), json.Number("2")},
+		"map":      map[string]interface{}{"foo": "bar"},
+		"nested":   map[string]interface{}{"foo": "bar"},
+		"Untagged": "untagged",
+	}, m1)
+
+	test.TestPointer = nil
+
+	m1, err = StructToMap(*test, nil)
+	assert.NoError(t, err)
+
+	m2, err = structToMapJSON(*test)
+	assert.NoError(t, err)
+
+	assert.Equal(t, m2, m1)
+}
+
+func TestStructToMapPlanInvalidFloat(t *testing.T) {
+	var test struct {
+		Foo float64
+	}
+
+	test.Foo = math.Inf(1)
+
+	m, err := StructToMap(&test, nil)
+	assert.Error(t, err)
+	assert.Nil(t, m)
+}
+
+func TestMapAssignPlan(t *testing.T) {
+	m, err := StructToMap(newTestPlan(), nil)
+	assert.NoError(t, err)
+
+	var test1 testPlan
+	err = m.Assign(&test1)
+	assert.NoError(t, err)
+
+	var test2 testPlan
+	err = m.assignJSON(&test2)
+	assert.NoError(t, err)
+
+	assert.Equal(t, test2, test1)
+
+	expected := newTestPlan()
+	expected.testEmbedded.Shadowed = ""
+	expected.Ignored = ""
+	expected.private = ""
+	assert.Equal(t, *expected, test1)
+}
+
+func TestMapAssignPlanConversion(t *testing.T) {
+	var test struct {
+		Int    int8
+		Uint   uint
+		Float  float32
+		Quoted bool `json:",string"`
+		Ptr    *int
+	}
+
+	err := Map{
+		"int":    json.Number("-5"),
+		"uint":   json.Number("5"),
+		"float":  json.Number("1.5"),
+		"quoted": "true",
+		"ptr":    json.Number("7"),
+	}.Assign(&test)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(-5), test.Int)
+	assert.Equal(t, uint(5), test.Uint)
+	assert.Equal(t, float32(1.5), test.Float)
+	assert.True(t, test.Quoted)
+	assert.Equal(t, 7, *test.Ptr)
+
+	err = Map{"int": json.Number("128")}.Assign(&test)
+	assert.Error(t, err)
+
+	err = Map{"uint": json.Number("-1")}.Assign(&test)
+	assert.Error(t, err)
+
+	err = Map{"quoted": true}.Assign(&test)
+	assert.Error(t, err)
+
+	err = Map{"ptr": nil}.Assign(&test)
+	assert.NoError(t, err)
+	assert.Nil(t, test.Ptr)
+}
+
+func TestMapPlanQuoted(t *testing.T) {
+	type quoted struct {
+		Int       int     `json:"int,string"`
+		IntPtr    *int    `json:"int_ptr,string"`
+		String    string  `json:"string,string"`
+		StringPtr *string `json:"string_ptr,string"`
+		NilPtr    *int    `json:"nil_ptr,string"`
+	}
+
+	num := 7
+	str := "bar"
+	test := quoted{
+		Int:       5,
+		IntPtr:    &num,
+		String:    "foo",
+		StringPtr: &str,
+	}
+
+	m1, err := StructToMap(test, nil)
+	assert.NoError(t, err)
+
+	m2, err := structToMapJSON(test)
+	assert.NoError(t, err)
+
+	assert.Equal(t, m2, m1)
+	assert.Equal(t, Map{
+		"int":        "5",
+		"int_ptr":    "7",
+		"string":     `"foo"`,
+		"string_ptr": `"bar"`,
+		"nil_ptr":    nil,
+	}, m1)
+
+	var test1 quoted
+	err = m1.Assign(&test1)
+	assert.NoError(t, err)
+
+	var test2 quoted
+	err = m1.assignJSON(&test2)
+	assert.NoError(t, err)
+
+	assert.Equal(t, test2, test1)
+	assert.Equal(t, test, test1)
+
+	err = Map{"int_ptr": json.Number("7")}.Assign(&test1)
+	assert.Error(t, err)
+
+	err = Map{"string": "foo"}.Assign(&test1)
+	assert.Error(t, err)
+}
+
+type testCustom struct {
+	A string
+}
+
+func (c testCustom) MarshalJSON() ([]byte, error) {
+	return []byte(`{"custom":"yes"}`), nil
+}
+
+func (c *testCustom) UnmarshalJSON([]byte) error {
+	c.A = "custom"
+	return nil
+}
+
+func TestStructToMapCustomMarshaller(t *testing.T) {
+	m, err := StructToMap(testCustom{A: "x"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Map{"custom": "yes"}, m)
+
+	m, err = StructToMap(&testCustom{A: "x"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Map{"custom": "yes"}, m)
+}
+
+func TestMapAssignCustomUnmarshaller(t *testing.T) {
+	var test testCustom
+	err := Map{"A": "x"}.Assign(&test)
+	assert.NoError(t, err)
+	assert.Equal(t, "custom", test.A)
+}
+
+func TestMapAssignCaseInsensitive(t *testing.T) {
+	type test struct {
+		Foo string
+		Bar string `json:"bar"`
+	}
+
+	for _, m := range []Map{
+		{"foo": "a", "FOO": "b", "Foo": "c"},
+		{"foo": "a", "FOO": "b", "fOo": "c"},
+		{"FOO": "b", "fOo": "c"},
+		{"Foo": "a", "fOO": "b", "BAR": "c", "bar": "d", "bAr": "e"},
+	} {
+		for i := 0; i < 10; i++ {
+			var test1 test
+			err := m.Assign(&test1)
+			assert.NoError(t, err)
+
+			var test2 test
+			err = m.assignJSON(&test2)
+			assert.NoError(t, err)
+
+			assert.Equal(t, test2, test1)
+		}
+	}
+
+	var test1 test
+	err := Map{"FOO": "b", "fOo": "c", "Bar": "d", "bar": "e"}.Assign(&test1)
+	assert.NoError(t, err)
+	assert.Equal(t, test{Foo: "c", Bar: "e"}, test1)
+}
+
 func BenchmarkStructToMap(b *testing.B) {
 	var test struct {
 		Foo string
@@ -205,6 +496,43 @@ func BenchmarkStructToMap(b *testing.B) {
 	}
 }
 
+func BenchmarkStructToMapJSON(b *testing.B) {
+	var test struct {
+		Foo string
+	}
+
+	test.Foo = "foo"
+
+	for i := 0; i < b.N; i++ {
+		_, err := structToMapJSON(&test)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkStructToMapPlan(b *testing.B) {
+	test := newTestPlan()
+
+	for i := 0; i < b.N; i++ {
+		_, err := StructToMap(test, nil)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkStructToMapPlanJSON(b *testing.B) {
+	test := newTestPlan()
+
+	for i := 0; i < b.N; i++ {
+		_, err := structToMapJSON(test)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
 func BenchmarkMapAssign(b *testing.B) {
 	var test struct {
 		Foo string
@@ -219,3 +547,54 @@ func BenchmarkMapAssign(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkMapAssignJSON(b *testing.B) {
+	var test struct {
+		Foo string
+	}
+
+	m := Map{"foo": "foo"}
+
+	for i := 0; i < b.N; i++ {
+		err := m.assignJSON(&test)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkMapAssignPlan(b *testing.B) {
+	m, err := StructToMap(newTestPlan(), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	var test testPlan
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := m.Assign(&test)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkMapAssignPlanJSON(b *testing.B) {
+	m, err := StructToMap(newTestPlan(), nil)
+	if err != nil {
+		panic(err)
+	}
+
+	var test testPlan
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := m.assignJSON(&test)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
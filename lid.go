@@ -0,0 +1,102 @@
+package jsonapi
+
+// LocalIDs tracks the ids assigned by a server to resources that have been
+// created using a local id. The map is keyed by resource type and local id.
+type LocalIDs map[string]map[string]string
+
+// Add will record the id of the passed resource if it has both an id and a
+// local id.
+func (l LocalIDs) Add(res *Resource) {
+	// check resource
+	if res.ID == "" || res.LID == "" {
+		return
+	}
+
+	// get ids
+	ids := l[res.Type]
+	if ids == nil {
+		ids = map[string]string{}
+		l[res.Type] = ids
+	}
+
+	// set id
+	ids[res.LID] = res.ID
+}
+
+// Lookup will return the id recorded for the specified local id.
+func (l LocalIDs) Lookup(typ, lid string) (string, bool) {
+	id, ok := l[typ][lid]
+	return id, ok
+}
+
+// Resolve will replace the local ids of all resource identifiers in the
+// relationships of the passed resource with the recorded ids. The identifiers
+// are modified in place. An error is returned if a local id is unknown.
+func (l LocalIDs) Resolve(res *Resource) error {
+	for _, rel := range res.Relationships {
+		// check data
+		if rel == nil || rel.Data == nil {
+			continue
+		}
+
+		// resolve single identifier
+		if rel.Data.One != nil {
+			err := l.resolve(rel.Data.One)
+			if err != nil {
+				return err
+			}
+		}
+
+		// resolve multiple identifiers
+		for _, id := range rel.Data.Many {
+			err := l.resolve(id)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l LocalIDs) resolve(res *Resource) error {
+	// check identifier
+	if res == nil || res.ID != "" || res.LID == "" {
+		return nil
+	}
+
+	// lookup id
+	id, ok := l.Lookup(res.Type, res.LID)
+	if !ok {
+		return BadRequest("unknown local id")
+	}
+
+	// replace local id
+	res.ID = id
+	res.LID = ""
+
+	return nil
+}
+
+// referencesLocalID returns whether the relationships of the passed resource
+// reference the specified local id.
+func referencesLocalID(res *Resource, typ, lid string) bool {
+	for _, rel := range res.Relationships {
+		// check data
+		if rel == nil || rel.Data == nil {
+			continue
+		}
+
+		// check identifiers
+		if rel.Data.One != nil && rel.Data.One.Type == typ && rel.Data.One.ID == "" && rel.Data.One.LID == lid {
+			return true
+		}
+		for _, id := range rel.Data.Many {
+			if id != nil && id.Type == typ && id.ID == "" && id.LID == lid {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package jsonapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalIDs(t *testing.T) {
+	lids := LocalIDs{}
+	lids.Add(&Resource{Type: "users", ID: "1", LID: "a"})
+	lids.Add(&Resource{Type: "users", ID: "2"})
+	lids.Add(&Resource{Type: "users", LID: "c"})
+
+	id, ok := lids.Lookup("users", "a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", id)
+
+	_, ok = lids.Lookup("posts", "a")
+	assert.False(t, ok)
+
+	_, ok = lids.Lookup("users", "c")
+	assert.False(t, ok)
+
+	res := &Resource{
+		Type: "posts",
+		LID:  "b",
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{
+					One: &Resource{Type: "users", LID: "a"},
+				},
+			},
+			"editors": {
+				Data: &HybridResource{
+					Many: []*Resource{
+						{Type: "users", ID: "2"},
+						{Type: "users", LID: "a"},
+					},
+				},
+			},
+			"comments": {},
+		},
+	}
+	err := lids.Resolve(res)
+	assert.NoError(t, err)
+	assert.Equal(t, &Resource{
+		Type: "posts",
+		LID:  "b",
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{
+					One: &Resource{Type: "users", ID: "1"},
+				},
+			},
+			"editors": {
+				Data: &HybridResource{
+					Many: []*Resource{
+						{Type: "users", ID: "2"},
+						{Type: "users", ID: "1"},
+					},
+				},
+			},
+			"comments": {},
+		},
+	}, res)
+
+	err = lids.Resolve(&Resource{
+		Type: "posts",
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{
+					One: &Resource{Type: "users", LID: "c"},
+				},
+			},
+		},
+	})
+	assert.Equal(t, BadRequest("unknown local id"), err)
+}
@@ -0,0 +1,143 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// NullLink can be used as a link reference to encode the link as a null value.
+const NullLink = "NULL"
+
+var nullBytes = []byte("null")
+
+// Link is a link that is encoded as a plain string if only a reference is set
+// and as a link object otherwise. It is encoded as null if the reference is
+// set to NullLink.
+//
+// Note: Links used to be plain strings. Code that set or read link strings
+// directly must now use the Href field, e.g. &Link{Href: "/posts/1"}.
+//
+// See: https://jsonapi.org/format/1.1/#document-links-link.
+type Link struct {
+	// The URI reference of the link.
+	Href string
+
+	// The relation type of the link.
+	Rel string
+
+	// A link to a description document for the link target.
+	DescribedBy *Link
+
+	// A human-readable label for the link destination.
+	Title string
+
+	// The media type of the link target.
+	Type string
+
+	// The languages of the link target.
+	HrefLang []string
+
+	// Non-standard meta-information about the link.
+	//
+	// Note: Numbers are left as strings to avoid issues with mismatching types
+	// when they are later assigned to a struct.
+	Meta Map
+}
+
+type linkObject struct {
+	Href        string      `json:"href"`
+	Rel         string      `json:"rel,omitempty"`
+	DescribedBy *Link       `json:"describedby,omitempty"`
+	Title       string      `json:"title,omitempty"`
+	Type        string      `json:"type,omitempty"`
+	HrefLang    interface{} `json:"hreflang,omitempty"`
+	Meta        Map         `json:"meta,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (l Link) MarshalJSON() ([]byte, error) {
+	// handle null
+	if l.Href == NullLink {
+		return nullBytes, nil
+	}
+
+	// handle string
+	if l.Rel == "" && l.DescribedBy == nil && l.Title == "" && l.Type == "" && len(l.HrefLang) == 0 && len(l.Meta) == 0 {
+		return json.Marshal(l.Href)
+	}
+
+	// prepare object
+	obj := linkObject{
+		Href:        l.Href,
+		Rel:         l.Rel,
+		DescribedBy: l.DescribedBy,
+		Title:       l.Title,
+		Type:        l.Type,
+		Meta:        l.Meta,
+	}
+
+	// set languages
+	if len(l.HrefLang) == 1 {
+		obj.HrefLang = l.HrefLang[0]
+	} else if len(l.HrefLang) > 1 {
+		obj.HrefLang = l.HrefLang
+	}
+
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (l *Link) UnmarshalJSON(data []byte) error {
+	// handle null
+	if bytes.Equal(data, nullBytes) {
+		*l = Link{Href: NullLink}
+		return nil
+	}
+
+	// handle string
+	if !bytes.HasPrefix(data, objectPrefix) {
+		*l = Link{}
+		return json.Unmarshal(data, &l.Href)
+	}
+
+	// prepare decoder
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// decode object
+	var obj linkObject
+	err := dec.Decode(&obj)
+	if err != nil {
+		return err
+	}
+
+	// set link
+	*l = Link{
+		Href:        obj.Href,
+		Rel:         obj.Rel,
+		DescribedBy: obj.DescribedBy,
+		Title:       obj.Title,
+		Type:        obj.Type,
+		Meta:        obj.Meta,
+	}
+
+	// set languages
+	switch lang := obj.HrefLang.(type) {
+	case nil:
+	case string:
+		l.HrefLang = []string{lang}
+	case []interface{}:
+		for _, item := range lang {
+			str, ok := item.(string)
+			if !ok {
+				return errors.New("expected hreflang to be a string or an array of strings")
+			}
+			l.HrefLang = append(l.HrefLang, str)
+		}
+	default:
+		return errors.New("expected hreflang to be a string or an array of strings")
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLink(t *testing.T) {
+	list := []struct {
+		link *Link
+		json string
+	}{
+		{
+			link: &Link{Href: "/posts"},
+			json: `"/posts"`,
+		},
+		{
+			link: &Link{Href: NullLink},
+			json: `null`,
+		},
+		{
+			link: &Link{
+				Href:        "/posts?page[number]=2",
+				Rel:         "next",
+				DescribedBy: &Link{Href: "/schemas/posts"},
+				Title:       "Next",
+				Type:        MediaType,
+				HrefLang:    []string{"en"},
+				Meta: Map{
+					"count": json.Number("42"),
+				},
+			},
+			json: `{
+				"href": "/posts?page[number]=2",
+				"rel": "next",
+				"describedby": "/schemas/posts",
+				"title": "Next",
+				"type": "application/vnd.api+json",
+				"hreflang": "en",
+				"meta": {
+					"count": 42
+				}
+			}`,
+		},
+		{
+			link: &Link{
+				Href:     "/posts",
+				HrefLang: []string{"en", "de"},
+			},
+			json: `{
+				"href": "/posts",
+				"hreflang": ["en", "de"]
+			}`,
+		},
+	}
+
+	for _, item := range list {
+		data, err := json.Marshal(item.link)
+		assert.NoError(t, err)
+		assert.JSONEq(t, item.json, string(data))
+
+		var link Link
+		err = json.Unmarshal([]byte(item.json), &link)
+		assert.NoError(t, err)
+		assert.Equal(t, item.link, &link)
+	}
+
+	var link Link
+	err := json.Unmarshal([]byte(`{"href": "/posts", "hreflang": 1}`), &link)
+	assert.Error(t, err)
+}
+
+func TestParseDocumentLinkObjects(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(`{
+		"links": {
+			"self": "/posts",
+			"describedby": "/schemas/posts",
+			"prev": null,
+			"next": {
+				"href": "/posts?page[number]=2",
+				"meta": {
+					"count": 10
+				}
+			}
+		},
+		"errors": []
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &DocumentLinks{
+		Self:        &Link{Href: "/posts"},
+		DescribedBy: &Link{Href: "/schemas/posts"},
+		Previous:    &Link{Href: NullLink},
+		Next: &Link{
+			Href: "/posts?page[number]=2",
+			Meta: Map{
+				"count": json.Number("10"),
+			},
+		},
+	}, doc.Links)
+}
+
+func TestErrorLinks(t *testing.T) {
+	data, err := json.Marshal(&Error{
+		Links: &ErrorLinks{
+			About: &Link{Href: "/errors/1"},
+			Type:  &Link{Href: "https://example.com/errors/invalid", Title: "Invalid"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"links": {
+			"about": "/errors/1",
+			"type": {
+				"href": "https://example.com/errors/invalid",
+				"title": "Invalid"
+			}
+		}
+	}`, string(data))
+}
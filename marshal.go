@@ -0,0 +1,536 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	tagPrimary = "primary"
+	tagAttr    = "attr"
+	tagRel     = "rel"
+)
+
+type structField struct {
+	index     int
+	name      string
+	typ       string
+	omitEmpty bool
+	toMany    bool
+	fast      bool
+}
+
+type structSpec struct {
+	typ           string
+	primary       int
+	attributes    []structField
+	relationships []structField
+}
+
+var structSpecs sync.Map
+
+// getStructSpec will return the cached spec for the passed struct type.
+func getStructSpec(t reflect.Type) (*structSpec, error) {
+	// check cache
+	if value, ok := structSpecs.Load(t); ok {
+		return value.(*structSpec), nil
+	}
+
+	// check type
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected struct, got %s", t.Kind())
+	}
+
+	// prepare spec
+	spec := &structSpec{
+		primary: -1,
+	}
+
+	// parse fields
+	for i := 0; i < t.NumField(); i++ {
+		// get field
+		field := t.Field(i)
+
+		// get tag
+		tag, ok := field.Tag.Lookup("jsonapi")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		// check export
+		if field.PkgPath != "" {
+			return nil, fmt.Errorf("tagged field %s.%s is not exported", t.Name(), field.Name)
+		}
+
+		// split tag
+		args := strings.Split(tag, ",")
+
+		// handle kind
+		switch args[0] {
+		case tagPrimary:
+			// check arguments
+			if len(args) != 2 || args[1] == "" {
+				return nil, fmt.Errorf("expected primary tag of %s.%s to specify a type", t.Name(), field.Name)
+			} else if spec.primary >= 0 {
+				return nil, fmt.Errorf("found duplicate primary field %s.%s", t.Name(), field.Name)
+			}
+
+			// check field type
+			typ := field.Type
+			if typ.Kind() == reflect.Ptr {
+				typ = typ.Elem()
+			}
+			switch typ.Kind() {
+			case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			default:
+				return nil, fmt.Errorf("unsupported primary field type %s", field.Type)
+			}
+
+			// set primary
+			spec.typ = args[1]
+			spec.primary = i
+		case tagAttr:
+			// check arguments
+			if len(args) < 2 || args[1] == "" {
+				return nil, fmt.Errorf("expected attr tag of %s.%s to specify a name", t.Name(), field.Name)
+			}
+
+			// add attribute
+			spec.attributes = append(spec.attributes, structField{
+				index:     i,
+				name:      args[1],
+				omitEmpty: len(args) > 2 && args[2] == "omitempty",
+				fast:      isFastType(field.Type),
+			})
+		case tagRel:
+			// check arguments
+			if len(args) < 2 || args[1] == "" {
+				return nil, fmt.Errorf("expected rel tag of %s.%s to specify a name", t.Name(), field.Name)
+			}
+
+			// prepare relationship
+			rel := structField{
+				index: i,
+				name:  args[1],
+			}
+
+			// get element type
+			elem := field.Type
+			if elem.Kind() == reflect.Slice {
+				rel.toMany = true
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+
+			// get related type
+			switch elem.Kind() {
+			case reflect.Struct:
+				_, typ := findPrimary(elem)
+				if typ == "" {
+					return nil, fmt.Errorf("related struct %s has no primary field", elem.Name())
+				}
+				rel.typ = typ
+			case reflect.String:
+				if len(args) < 3 || args[2] == "" {
+					return nil, fmt.Errorf("expected rel tag of %s.%s to specify a type", t.Name(), field.Name)
+				}
+				rel.typ = args[2]
+			default:
+				return nil, fmt.Errorf("unsupported relationship field type %s", field.Type)
+			}
+
+			// add relationship
+			spec.relationships = append(spec.relationships, rel)
+		default:
+			return nil, fmt.Errorf("unknown tag %q on field %s.%s", args[0], t.Name(), field.Name)
+		}
+	}
+
+	// check primary
+	if spec.primary < 0 {
+		return nil, fmt.Errorf("missing primary field on struct %s", t.Name())
+	}
+
+	// cache spec
+	structSpecs.Store(t, spec)
+
+	return spec, nil
+}
+
+// MarshalResource will convert the passed struct to a resource using the
+// "jsonapi" struct tags of its fields. The following tags are supported:
+//
+//	ID     string   `jsonapi:"primary,posts"`
+//	Title  string   `jsonapi:"attr,title"`
+//	Body   string   `jsonapi:"attr,body,omitempty"`
+//	Author *User    `jsonapi:"rel,author"`
+//	Tags   []*Tag   `jsonapi:"rel,tags"`
+//	Editor string   `jsonapi:"rel,editor,users"`
+//	Likes  []string `jsonapi:"rel,likes,users"`
+//
+// Attributes are encoded to the values produced by the parser e.g. numbers are
+// represented as json.Number values. The id of a resource is only omitted if
+// the primary field is an empty string or a nil pointer, which allows numeric
+// primary fields to mark new resources by using a pointer.
+//
+// Relationships are encoded as resource identifiers. Pointer and struct fields
+// represent to-one relationships while slice fields represent to-many
+// relationships. Related structs must have a primary field while string
+// fields require the related type to be specified in the tag.
+func MarshalResource(v interface{}) (*Resource, error) {
+	// get value
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, fmt.Errorf("cannot marshal nil value")
+		}
+		value = value.Elem()
+	}
+
+	// get spec
+	spec, err := getStructSpec(value.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare resource
+	res := &Resource{
+		Type: spec.typ,
+		ID:   formatPrimary(value.Field(spec.primary)),
+	}
+
+	// set attributes
+	for _, attr := range spec.attributes {
+		// get field
+		field := value.Field(attr.index)
+		if attr.omitEmpty && isEmptyValue(field) {
+			continue
+		}
+
+		// ensure map
+		if res.Attributes == nil {
+			res.Attributes = Map{}
+		}
+
+		// set attribute
+		res.Attributes[attr.name], err = encodeValue(field, attr.fast, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// set relationships
+	for _, rel := range spec.relationships {
+		// get field
+		field := value.Field(rel.index)
+
+		// prepare data
+		data := &HybridResource{}
+
+		// handle to-many and to-one relationships
+		if rel.toMany {
+			data.Many = make([]*Resource, 0, field.Len())
+			for i := 0; i < field.Len(); i++ {
+				id, ok := relatedID(field.Index(i))
+				if ok {
+					data.Many = append(data.Many, &Resource{Type: rel.typ, ID: id})
+				}
+			}
+		} else {
+			id, ok := relatedID(field)
+			if ok {
+				data.One = &Resource{Type: rel.typ, ID: id}
+			}
+		}
+
+		// ensure map
+		if res.Relationships == nil {
+			res.Relationships = map[string]*Relationship{}
+		}
+
+		// set relationship
+		res.Relationships[rel.name] = &Relationship{
+			Data: data,
+		}
+	}
+
+	return res, nil
+}
+
+// UnmarshalResource will assign the type, id, attributes and relationships of
+// the passed resource to the specified struct pointer using the "jsonapi"
+// struct tags of its fields. See MarshalResource for the supported tags.
+//
+// Note: Attributes and relationships that are missing from the resource will
+// leave the corresponding fields untouched. The same applies to relationships
+// without resource linkage e.g. if only links are present.
+func UnmarshalResource(res *Resource, v interface{}) error {
+	// check resource
+	if res == nil {
+		return fmt.Errorf("cannot unmarshal nil resource")
+	}
+
+	// get value
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("expected non nil pointer")
+	}
+	value = value.Elem()
+
+	// get spec
+	spec, err := getStructSpec(value.Type())
+	if err != nil {
+		return err
+	}
+
+	// check type
+	if res.Type != spec.typ {
+		return BadRequest("resource type mismatch")
+	}
+
+	// set id
+	err = parsePrimary(value.Field(spec.primary), res.ID)
+	if err != nil {
+		return BadRequest("invalid resource id")
+	}
+
+	// set attributes
+	for _, attr := range spec.attributes {
+		// get attribute
+		val, ok := res.Attributes[attr.name]
+		if !ok {
+			continue
+		}
+
+		// assign attribute
+		err = assignValue(value.Field(attr.index), val, attr.fast)
+		if err != nil {
+			return BadRequest(fmt.Sprintf("invalid attribute %q", attr.name))
+		}
+	}
+
+	// set relationships
+	for _, rel := range spec.relationships {
+		// get relationship
+		relationship, ok := res.Relationships[rel.name]
+		if !ok || relationship == nil || relationship.Data == nil {
+			continue
+		}
+
+		// get field
+		field := value.Field(rel.index)
+
+		// handle to-many relationships
+		if rel.toMany {
+			// check data
+			if relationship.Data.Many == nil {
+				return BadRequest(fmt.Sprintf("expected to-many relationship %q", rel.name))
+			}
+
+			// prepare slice
+			slice := reflect.MakeSlice(field.Type(), 0, len(relationship.Data.Many))
+
+			// add elements
+			for _, id := range relationship.Data.Many {
+				// check type
+				if id.Type != rel.typ {
+					return BadRequest(fmt.Sprintf("relationship type mismatch %q", rel.name))
+				}
+
+				// create element
+				elem := reflect.New(field.Type().Elem()).Elem()
+				err = setRelatedID(elem, id.ID)
+				if err != nil {
+					return BadRequest(fmt.Sprintf("invalid relationship id %q", rel.name))
+				}
+
+				// append element
+				slice = reflect.Append(slice, elem)
+			}
+
+			// set slice
+			field.Set(slice)
+
+			continue
+		}
+
+		// check data
+		if relationship.Data.Many != nil {
+			return BadRequest(fmt.Sprintf("expected to-one relationship %q", rel.name))
+		}
+
+		// handle null
+		if relationship.Data.One == nil {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+
+		// check type
+		if relationship.Data.One.Type != rel.typ {
+			return BadRequest(fmt.Sprintf("relationship type mismatch %q", rel.name))
+		}
+
+		// set related id
+		elem := reflect.New(field.Type()).Elem()
+		err = setRelatedID(elem, relationship.Data.One.ID)
+		if err != nil {
+			return BadRequest(fmt.Sprintf("invalid relationship id %q", rel.name))
+		}
+		field.Set(elem)
+	}
+
+	return nil
+}
+
+// findPrimary will return the index and type of the primary field of the
+// passed struct type without building a full spec. This allows structs to
+// reference each other through relationships.
+func findPrimary(t reflect.Type) (int, string) {
+	for i := 0; i < t.NumField(); i++ {
+		args := strings.Split(t.Field(i).Tag.Get("jsonapi"), ",")
+		if len(args) == 2 && args[0] == tagPrimary {
+			return i, args[1]
+		}
+	}
+
+	return -1, ""
+}
+
+func formatPrimary(v reflect.Value) string {
+	// handle unset ids of new resources
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+}
+
+func parsePrimary(v reflect.Value, id string) error {
+	// handle pointers
+	if v.Kind() == reflect.Ptr {
+		if id == "" {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(id)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if id == "" {
+			v.SetInt(0)
+			return nil
+		}
+		n, err := strconv.ParseInt(id, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	default:
+		if id == "" {
+			v.SetUint(0)
+			return nil
+		}
+		n, err := strconv.ParseUint(id, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	}
+
+	return nil
+}
+
+func relatedID(v reflect.Value) (string, bool) {
+	// handle pointers
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+
+	// handle strings
+	if v.Kind() == reflect.String {
+		return v.String(), v.String() != ""
+	}
+
+	// get primary
+	index, _ := findPrimary(v.Type())
+	id := formatPrimary(v.Field(index))
+
+	return id, id != ""
+}
+
+func setRelatedID(v reflect.Value, id string) error {
+	// handle pointers
+	if v.Kind() == reflect.Ptr {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+
+	// handle strings
+	if v.Kind() == reflect.String {
+		v.SetString(id)
+		return nil
+	}
+
+	// get primary
+	index, _ := findPrimary(v.Type())
+
+	return parsePrimary(v.Field(index), id)
+}
+
+func assignValue(v reflect.Value, val interface{}, fast bool) error {
+	// prepare target
+	target := reflect.New(v.Type()).Elem()
+
+	// decode value
+	err := decodeValue(target, val, fast, false)
+	if err != nil {
+		return err
+	}
+
+	// set value
+	v.Set(target)
+
+	return nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	return false
+}
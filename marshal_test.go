@@ -0,0 +1,363 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser struct {
+	ID    int64       `jsonapi:"primary,users"`
+	Name  string      `jsonapi:"attr,name"`
+	Posts []*testPost `jsonapi:"rel,posts"`
+}
+
+type testPost struct {
+	ID       string    `jsonapi:"primary,posts"`
+	Title    string    `jsonapi:"attr,title"`
+	Body     string    `jsonapi:"attr,body,omitempty"`
+	Count    int       `jsonapi:"attr,count"`
+	Author   *testUser `jsonapi:"rel,author"`
+	Editor   string    `jsonapi:"rel,editor,users"`
+	Likes    []string  `jsonapi:"rel,likes,users"`
+	Internal string
+}
+
+type testNote struct {
+	ID   *int64 `jsonapi:"primary,notes"`
+	Text string `jsonapi:"attr,text"`
+}
+
+func TestMarshalResource(t *testing.T) {
+	res, err := MarshalResource(&testPost{
+		ID:     "1",
+		Title:  "Hello",
+		Count:  42,
+		Author: &testUser{ID: 7},
+		Likes:  []string{"8", "9"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &Resource{
+		Type: "posts",
+		ID:   "1",
+		Attributes: Map{
+			"title": "Hello",
+			"count": json.Number("42"),
+		},
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{
+					One: &Resource{Type: "users", ID: "7"},
+				},
+			},
+			"editor": {
+				Data: &HybridResource{},
+			},
+			"likes": {
+				Data: &HybridResource{
+					Many: []*Resource{
+						{Type: "users", ID: "8"},
+						{Type: "users", ID: "9"},
+					},
+				},
+			},
+		},
+	}, res)
+
+	res, err = MarshalResource(testUser{ID: 7, Name: "Joe"})
+	assert.NoError(t, err)
+	assert.Equal(t, &Resource{
+		Type: "users",
+		ID:   "7",
+		Attributes: Map{
+			"name": "Joe",
+		},
+		Relationships: map[string]*Relationship{
+			"posts": {
+				Data: &HybridResource{
+					Many: []*Resource{},
+				},
+			},
+		},
+	}, res)
+}
+
+func TestMarshalResourceNew(t *testing.T) {
+	res, err := MarshalResource(&testPost{
+		Title:  "Hello",
+		Author: &testUser{},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "", res.ID)
+	assert.Equal(t, json.Number("0"), res.Attributes["count"])
+	assert.Equal(t, &HybridResource{
+		One: &Resource{Type: "users", ID: "0"},
+	}, res.Relationships["author"].Data)
+
+	res, err = MarshalResource(&testUser{Name: "Joe"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0", res.ID)
+
+	res, err = MarshalResource(&testNote{Text: "Hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", res.ID)
+
+	buf, err := json.Marshal(res)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "notes",
+		"attributes": {
+			"text": "Hello"
+		}
+	}`, string(buf))
+
+	id := int64(0)
+	res, err = MarshalResource(&testNote{ID: &id, Text: "Hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0", res.ID)
+
+	var note testNote
+	err = UnmarshalResource(res, &note)
+	assert.NoError(t, err)
+	assert.Equal(t, testNote{ID: &id, Text: "Hello"}, note)
+
+	err = UnmarshalResource(&Resource{Type: "notes"}, &note)
+	assert.NoError(t, err)
+	assert.Nil(t, note.ID)
+}
+
+func TestMarshalResourceJSON(t *testing.T) {
+	res, err := MarshalResource(&testUser{
+		ID:   7,
+		Name: "Joe",
+	})
+	assert.NoError(t, err)
+
+	buf, err := json.Marshal(res)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "users",
+		"id": "7",
+		"attributes": {
+			"name": "Joe"
+		},
+		"relationships": {
+			"posts": {
+				"data": []
+			}
+		}
+	}`, string(buf))
+}
+
+func TestMarshalResourceErrors(t *testing.T) {
+	var nilPost *testPost
+	_, err := MarshalResource(nilPost)
+	assert.Error(t, err)
+
+	_, err = MarshalResource("foo")
+	assert.Error(t, err)
+
+	_, err = MarshalResource(struct {
+		Title string `jsonapi:"attr,title"`
+	}{})
+	assert.Error(t, err)
+
+	_, err = MarshalResource(struct {
+		ID   string `jsonapi:"primary,foo"`
+		Rels string `jsonapi:"rel,bar"`
+	}{})
+	assert.Error(t, err)
+
+	_, err = MarshalResource(struct {
+		ID  string `jsonapi:"primary,foo"`
+		Foo string `jsonapi:"foo"`
+	}{})
+	assert.Error(t, err)
+}
+
+func TestUnmarshalResource(t *testing.T) {
+	var post testPost
+	err := UnmarshalResource(&Resource{
+		Type: "posts",
+		ID:   "1",
+		Attributes: Map{
+			"title": "Hello",
+			"count": json.Number("42"),
+		},
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{
+					One: &Resource{Type: "users", ID: "7"},
+				},
+			},
+			"likes": {
+				Data: &HybridResource{
+					Many: []*Resource{
+						{Type: "users", ID: "8"},
+						{Type: "users", ID: "9"},
+					},
+				},
+			},
+		},
+	}, &post)
+	assert.NoError(t, err)
+	assert.Equal(t, testPost{
+		ID:     "1",
+		Title:  "Hello",
+		Count:  42,
+		Author: &testUser{ID: 7},
+		Likes:  []string{"8", "9"},
+	}, post)
+
+	var user testUser
+	err = UnmarshalResource(&Resource{
+		Type: "users",
+		ID:   "7",
+		Relationships: map[string]*Relationship{
+			"posts": {
+				Data: &HybridResource{
+					Many: []*Resource{
+						{Type: "posts", ID: "1"},
+					},
+				},
+			},
+		},
+	}, &user)
+	assert.NoError(t, err)
+	assert.Equal(t, testUser{
+		ID: 7,
+		Posts: []*testPost{
+			{ID: "1"},
+		},
+	}, user)
+}
+
+func TestUnmarshalResourceNullRelationship(t *testing.T) {
+	post := testPost{
+		Author: &testUser{ID: 7},
+	}
+	err := UnmarshalResource(&Resource{
+		Type: "posts",
+		ID:   "1",
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{},
+			},
+		},
+	}, &post)
+	assert.NoError(t, err)
+	assert.Nil(t, post.Author)
+}
+
+func TestUnmarshalResourceMissingLinkage(t *testing.T) {
+	var res Resource
+	err := json.Unmarshal([]byte(`{
+		"type": "posts",
+		"id": "1",
+		"relationships": {
+			"author": {
+				"links": {
+					"related": "/posts/1/author"
+				}
+			},
+			"likes": {
+				"links": {
+					"related": "/posts/1/likes"
+				}
+			},
+			"editor": {
+				"data": null
+			}
+		}
+	}`), &res)
+	assert.NoError(t, err)
+
+	post := testPost{
+		Author: &testUser{ID: 7},
+		Editor: "8",
+		Likes:  []string{"9"},
+	}
+	err = UnmarshalResource(&res, &post)
+	assert.NoError(t, err)
+	assert.Equal(t, testPost{
+		ID:     "1",
+		Author: &testUser{ID: 7},
+		Likes:  []string{"9"},
+	}, post)
+}
+
+func TestUnmarshalResourceErrors(t *testing.T) {
+	var post testPost
+
+	err := UnmarshalResource(nil, &post)
+	assert.Error(t, err)
+
+	err = UnmarshalResource(&Resource{Type: "posts"}, post)
+	assert.Error(t, err)
+
+	err = UnmarshalResource(&Resource{Type: "users"}, &post)
+	assert.Equal(t, BadRequest("resource type mismatch"), err)
+
+	err = UnmarshalResource(&Resource{Type: "users", ID: "foo"}, &testUser{})
+	assert.Equal(t, BadRequest("invalid resource id"), err)
+
+	err = UnmarshalResource(&Resource{
+		Type: "posts",
+		Attributes: Map{
+			"count": "foo",
+		},
+	}, &post)
+	assert.Equal(t, BadRequest(`invalid attribute "count"`), err)
+
+	err = UnmarshalResource(&Resource{
+		Type: "posts",
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{
+					One: &Resource{Type: "posts", ID: "1"},
+				},
+			},
+		},
+	}, &post)
+	assert.Equal(t, BadRequest(`relationship type mismatch "author"`), err)
+
+	err = UnmarshalResource(&Resource{
+		Type: "posts",
+		Relationships: map[string]*Relationship{
+			"likes": {
+				Data: &HybridResource{
+					One: &Resource{Type: "users", ID: "1"},
+				},
+			},
+		},
+	}, &post)
+	assert.Equal(t, BadRequest(`expected to-many relationship "likes"`), err)
+
+	err = UnmarshalResource(&Resource{
+		Type: "posts",
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{
+					Many: []*Resource{},
+				},
+			},
+		},
+	}, &post)
+	assert.Equal(t, BadRequest(`expected to-one relationship "author"`), err)
+}
+
+func BenchmarkMarshalResource(b *testing.B) {
+	post := &testPost{
+		ID:     "1",
+		Title:  "Hello",
+		Author: &testUser{ID: 7},
+	}
+
+	for i := 0; i < b.N; i++ {
+		_, err := MarshalResource(post)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package jsonapi
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// negotiate will check the content type and accept header of the passed
+// request and set the applied extensions and profiles.
+//
+// See: https://jsonapi.org/format/1.1/#content-negotiation-servers.
+func (p *Parser) negotiate(r *http.Request, req *Request) error {
+	// check content type header
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" {
+		// parse content type
+		typ, params, err := mime.ParseMediaType(contentType)
+		if err != nil || typ != MediaType {
+			return BadRequest("invalid content type header")
+		}
+
+		// check parameters
+		for name := range params {
+			if name != "ext" && name != "profile" {
+				return ErrorFromStatus(http.StatusUnsupportedMediaType, "unsupported media type parameter")
+			}
+		}
+
+		// check extensions
+		extensions := splitList(params["ext"])
+		for _, ext := range extensions {
+			if !p.supportsExtension(ext) {
+				return ErrorFromStatus(http.StatusUnsupportedMediaType, "unsupported extension")
+			}
+		}
+
+		// set extensions and profiles
+		req.Extensions = extensions
+		req.Profiles = p.supportedProfiles(params["profile"])
+	}
+
+	// check accept header
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return nil
+	}
+
+	// find acceptable media types, generic media types are only acceptable if
+	// no instances of the JSON API media type are present
+	var generic bool
+	var found bool
+	var selected map[string]string
+	var quality float64
+	for _, item := range strings.Split(accept, ",") {
+		// parse media type
+		typ, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+
+		// get quality
+		q := 1.0
+		if str, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(str, 64)
+			if err != nil || q <= 0 {
+				continue
+			}
+			delete(params, "q")
+		}
+
+		// accept generic media types
+		if typ == "*/*" || typ == "application/*" || typ == "application/json" {
+			generic = true
+			continue
+		}
+
+		// check media type
+		if typ != MediaType {
+			continue
+		}
+		found = true
+
+		// check parameters
+		if !p.acceptsParams(params) {
+			continue
+		}
+
+		// select media type with highest quality
+		if selected == nil || q > quality {
+			selected = params
+			quality = q
+		}
+	}
+	if selected == nil && (found || !generic) {
+		return ErrorFromStatus(http.StatusNotAcceptable, "invalid accept header")
+	}
+
+	// set extensions and profiles from selected media type if missing
+	if contentType == "" && selected != nil {
+		req.Extensions = splitList(selected["ext"])
+		req.Profiles = p.supportedProfiles(selected["profile"])
+	}
+
+	return nil
+}
+
+// acceptsParams returns whether the media type parameters of an accept header
+// instance are acceptable.
+func (p *Parser) acceptsParams(params map[string]string) bool {
+	// check parameters
+	for name := range params {
+		if name != "ext" && name != "profile" {
+			return false
+		}
+	}
+
+	// check extensions
+	for _, ext := range splitList(params["ext"]) {
+		if !p.supportsExtension(ext) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// supportsExtension returns whether the specified extension is supported. The
+// atomic operations extension is supported if an endpoint is configured.
+func (p *Parser) supportsExtension(ext string) bool {
+	// check atomic operations
+	if ext == AtomicExtension && p.AtomicEndpoint != "" {
+		return true
+	}
+
+	// check extensions
+	for _, e := range p.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+
+	return false
+}
+
+// supportedProfiles will return the supported profiles from the passed space
+// separated list. Unsupported profiles are ignored.
+func (p *Parser) supportedProfiles(list string) []string {
+	// collect profiles
+	var profiles []string
+	for _, profile := range strings.Fields(list) {
+		for _, pr := range p.Profiles {
+			if pr == profile {
+				profiles = append(profiles, profile)
+				break
+			}
+		}
+	}
+
+	return profiles
+}
+
+// splitList will split the passed space separated list and return nil for an
+// empty list.
+func splitList(list string) []string {
+	// split list
+	items := strings.Fields(list)
+	if len(items) == 0 {
+		return nil
+	}
+
+	return items
+}
+
+// mergeList will return a new list with the items of the passed list and the
+// additional items that are not yet present.
+func mergeList(list, items []string) []string {
+	// copy list
+	merged := make([]string, 0, len(list)+len(items))
+	merged = append(merged, list...)
+
+	// add missing items
+	for _, item := range items {
+		if !contains(merged, item) {
+			merged = append(merged, item)
+		}
+	}
+
+	return merged
+}
+
+// MediaType will return the JSON API media type with the extensions and
+// profiles applied to the request. It can be set as the content type of the
+// response before calling WriteResponse to echo the applied extensions and
+// profiles.
+func (r *Request) MediaType() string {
+	// prepare parameters
+	params := map[string]string{}
+	if len(r.Extensions) > 0 {
+		params["ext"] = strings.Join(r.Extensions, " ")
+	}
+	if len(r.Profiles) > 0 {
+		params["profile"] = strings.Join(r.Profiles, " ")
+	}
+
+	return mime.FormatMediaType(MediaType, params)
+}
@@ -0,0 +1,158 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRequestMediaType(t *testing.T) {
+	parser := &Parser{
+		Extensions: []string{"https://example.com/ext/foo"},
+		Profiles:   []string{"https://example.com/profile/bar"},
+	}
+
+	list := []struct {
+		contentType string
+		accept      string
+		err         string
+		extensions  []string
+		profiles    []string
+	}{
+		{
+			contentType: MediaType,
+			accept:      MediaType,
+		},
+		{
+			contentType: `application/vnd.api+json; ext="https://example.com/ext/foo"; profile="https://example.com/profile/bar https://example.com/profile/baz"`,
+			extensions:  []string{"https://example.com/ext/foo"},
+			profiles:    []string{"https://example.com/profile/bar"},
+		},
+		{
+			contentType: "application/vnd.api+json; charset=utf-8",
+			err:         "unsupported media type: unsupported media type parameter",
+		},
+		{
+			contentType: `application/vnd.api+json; ext="https://example.com/ext/baz"`,
+			err:         "unsupported media type: unsupported extension",
+		},
+		{
+			contentType: "application/json",
+			err:         "bad request: invalid content type header",
+		},
+		{
+			accept: "text/html, application/vnd.api+json; q=0.5",
+		},
+		{
+			accept:     `application/vnd.api+json; q=0.5, application/vnd.api+json; ext="https://example.com/ext/foo"; q=0.8`,
+			extensions: []string{"https://example.com/ext/foo"},
+		},
+		{
+			accept: `application/vnd.api+json; charset=utf-8, application/vnd.api+json; ext="https://example.com/ext/baz"`,
+			err:    "not acceptable: invalid accept header",
+		},
+		{
+			accept: "application/vnd.api+json; q=0",
+			err:    "not acceptable: invalid accept header",
+		},
+		{
+			accept: "application/vnd.api+json; charset=utf-8, */*; q=0.1",
+			err:    "not acceptable: invalid accept header",
+		},
+		{
+			accept: `application/vnd.api+json; ext="unknown", */*`,
+			err:    "not acceptable: invalid accept header",
+		},
+		{
+			accept: `application/vnd.api+json; ext="unknown", application/vnd.api+json, */*`,
+		},
+		{
+			accept: "text/html, application/json",
+		},
+		{
+			accept: "*/*",
+		},
+		{
+			accept: "text/html",
+			err:    "not acceptable: invalid accept header",
+		},
+	}
+
+	for _, item := range list {
+		r := newTestRequest("GET", "/posts")
+		if item.contentType != "" {
+			r.Header.Set("Content-Type", item.contentType)
+		}
+		if item.accept != "" {
+			r.Header.Set("Accept", item.accept)
+		}
+
+		req, err := parser.ParseRequest(r)
+		if item.err != "" {
+			assert.Error(t, err)
+			assert.Equal(t, item.err, err.Error())
+			assert.Nil(t, req)
+			continue
+		}
+
+		assert.NoError(t, err)
+		assert.Equal(t, item.extensions, req.Extensions)
+		assert.Equal(t, item.profiles, req.Profiles)
+	}
+}
+
+func TestRequestMediaType(t *testing.T) {
+	assert.Equal(t, MediaType, (&Request{}).MediaType())
+	assert.Equal(t, atomicMediaType, (&Request{
+		Extensions: []string{AtomicExtension},
+	}).MediaType())
+	assert.Equal(t, `application/vnd.api+json; ext="https://example.com/ext/foo https://example.com/ext/bar"; profile="https://example.com/profile/baz"`, (&Request{
+		Extensions: []string{"https://example.com/ext/foo", "https://example.com/ext/bar"},
+		Profiles:   []string{"https://example.com/profile/baz"},
+	}).MediaType())
+}
+
+func TestRequestMergeMediaType(t *testing.T) {
+	ext := Request{
+		Extensions: []string{"https://example.com/ext/foo", AtomicExtension},
+		Profiles:   []string{"https://example.com/profile/bar"},
+	}
+
+	req := Request{
+		Extensions: []string{AtomicExtension},
+	}.Merge(ext, ext)
+	assert.Equal(t, []string{AtomicExtension, "https://example.com/ext/foo"}, req.Extensions)
+	assert.Equal(t, []string{"https://example.com/profile/bar"}, req.Profiles)
+	assert.Equal(t, `application/vnd.api+json; ext="https://jsonapi.org/ext/atomic https://example.com/ext/foo"; profile="https://example.com/profile/bar"`, req.MediaType())
+}
+
+func TestServerMediaType(t *testing.T) {
+	server := NewServer(ServerConfig{})
+
+	r := httptest.NewRequest("POST", "/operations", strings.NewReader(`{
+		"atomic:operations": [{
+			"op": "add",
+			"data": {
+				"type": "posts"
+			}
+		}]
+	}`))
+	r.Header.Set("Content-Type", atomicMediaType)
+	r.Header.Set("Accept", atomicMediaType)
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, atomicMediaType, rec.Header().Get("Content-Type"))
+
+	r = httptest.NewRequest("GET", "/posts", nil)
+	r.Header.Set("Accept", "application/vnd.api+json; charset=utf-8")
+
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	assert.Equal(t, MediaType, rec.Header().Get("Content-Type"))
+}
@@ -0,0 +1,190 @@
+package jsonapi
+
+// CursorPaginationProfile is the URI of the cursor pagination profile.
+//
+// See: https://jsonapi.org/profiles/ethanresnick/cursor-pagination.
+const CursorPaginationProfile = "https://jsonapi.org/profiles/ethanresnick/cursor-pagination"
+
+// PaginationLinks will return the links for the pagination style used by the
+// request. The total number of resources is used to calculate the "next" and
+// "last" links, a negative total means it is unknown. For cursor pagination,
+// the first and second cursor are used as the "page[before]" value of the
+// "prev" link and the "page[after]" value of the "next" link. An empty cursor
+// will result in a null link. All other query parameters are preserved.
+//
+// Page numbers start at one. Only the "self" link is returned if the request
+// does not use pagination.
+func (r *Request) PaginationLinks(total int64, cursors ...string) *DocumentLinks {
+	// prepare links
+	links := &DocumentLinks{
+		Self: &Link{Href: r.Self()},
+	}
+
+	// handle styles
+	switch {
+	case r.PageBefore != "" || r.PageAfter != "" || len(cursors) > 0:
+		r.cursorLinks(links, cursors)
+	case r.PageOffset > 0 || r.PageLimit > 0:
+		r.offsetLinks(links, total)
+	case r.PageNumber > 0 || r.PageSize > 0:
+		r.numberLinks(links, total)
+	}
+
+	return links
+}
+
+func (r *Request) numberLinks(links *DocumentLinks, total int64) {
+	// check size
+	size := r.PageSize
+	if size <= 0 {
+		return
+	}
+
+	// get number
+	number := r.PageNumber
+	if number < 1 {
+		number = 1
+	}
+
+	// calculate last page
+	last := int64(-1)
+	if total >= 0 {
+		last = (total + size - 1) / size
+		if last < 1 {
+			last = 1
+		}
+	}
+
+	// set first and last link
+	links.First = r.pageLink(func(req *Request) { req.PageNumber = 1 })
+	if last > 0 {
+		links.Last = r.pageLink(func(req *Request) { req.PageNumber = last })
+	}
+
+	// set previous link
+	if number > 1 {
+		links.Previous = r.pageLink(func(req *Request) { req.PageNumber = number - 1 })
+	} else {
+		links.Previous = &Link{Href: NullLink}
+	}
+
+	// set next link
+	if last < 0 || number < last {
+		links.Next = r.pageLink(func(req *Request) { req.PageNumber = number + 1 })
+	} else {
+		links.Next = &Link{Href: NullLink}
+	}
+}
+
+func (r *Request) offsetLinks(links *DocumentLinks, total int64) {
+	// check limit
+	limit := r.PageLimit
+	if limit <= 0 {
+		return
+	}
+
+	// get offset
+	offset := r.PageOffset
+	if offset < 0 {
+		offset = 0
+	}
+
+	// set first link
+	links.First = r.pageLink(func(req *Request) { req.PageOffset = 0 })
+
+	// set last link, the last page is aligned to the current offset to keep
+	// it reachable using the previous and next links
+	if total >= 0 {
+		var last int64
+		if offset < total {
+			last = offset + (total-1-offset)/limit*limit
+		} else {
+			last = offset - ((offset-total)/limit+1)*limit
+		}
+		if last < 0 {
+			last = 0
+		}
+		links.Last = r.pageLink(func(req *Request) { req.PageOffset = last })
+	}
+
+	// set previous link
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		links.Previous = r.pageLink(func(req *Request) { req.PageOffset = prev })
+	} else {
+		links.Previous = &Link{Href: NullLink}
+	}
+
+	// set next link
+	if total < 0 || offset+limit < total {
+		links.Next = r.pageLink(func(req *Request) { req.PageOffset = offset + limit })
+	} else {
+		links.Next = &Link{Href: NullLink}
+	}
+}
+
+func (r *Request) cursorLinks(links *DocumentLinks, cursors []string) {
+	// get cursors
+	var before, after string
+	if len(cursors) > 0 {
+		before = cursors[0]
+	}
+	if len(cursors) > 1 {
+		after = cursors[1]
+	}
+
+	// set first link
+	links.First = r.pageLink(func(req *Request) {})
+
+	// set previous link
+	if before != "" {
+		links.Previous = r.pageLink(func(req *Request) { req.PageBefore = before })
+	} else {
+		links.Previous = &Link{Href: NullLink}
+	}
+
+	// set next link
+	if after != "" {
+		links.Next = r.pageLink(func(req *Request) { req.PageAfter = after })
+	} else {
+		links.Next = &Link{Href: NullLink}
+	}
+}
+
+func (r *Request) pageLink(fn func(*Request)) *Link {
+	// copy request
+	req := *r
+
+	// reset cursors
+	req.PageBefore = ""
+	req.PageAfter = ""
+
+	// keep page size
+	req.DefaultedPageSize = false
+
+	// apply changes
+	fn(&req)
+
+	return &Link{Href: req.Self()}
+}
+
+// PaginationMeta will return the top-level meta of the cursor pagination
+// profile. A negative total is omitted and the "rangeTruncated" member is only
+// set if the range has been truncated.
+func PaginationMeta(total int64, rangeTruncated bool) Map {
+	// prepare page
+	page := Map{}
+	if total >= 0 {
+		page["total"] = total
+	}
+	if rangeTruncated {
+		page["rangeTruncated"] = true
+	}
+
+	return Map{
+		"page": page,
+	}
+}
@@ -0,0 +1,246 @@
+package jsonapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestPaginationLinks(t *testing.T) {
+	null := &Link{Href: NullLink}
+	link := func(str string) *Link {
+		return &Link{Href: escape(str)}
+	}
+
+	// none
+	req := &Request{ResourceType: "posts", Sorting: []string{"title"}}
+	assert.Equal(t, &DocumentLinks{
+		Self: link("/posts?sort=title"),
+	}, req.PaginationLinks(10))
+
+	// number and size
+	req = &Request{ResourceType: "posts", PageNumber: 2, PageSize: 3, Sorting: []string{"title"}}
+	assert.Equal(t, &DocumentLinks{
+		Self:     link("/posts?page[number]=2&page[size]=3&sort=title"),
+		First:    link("/posts?page[number]=1&page[size]=3&sort=title"),
+		Previous: link("/posts?page[number]=1&page[size]=3&sort=title"),
+		Next:     link("/posts?page[number]=3&page[size]=3&sort=title"),
+		Last:     link("/posts?page[number]=4&page[size]=3&sort=title"),
+	}, req.PaginationLinks(10))
+
+	req = &Request{ResourceType: "posts", PageSize: 5}
+	assert.Equal(t, &DocumentLinks{
+		Self:     link("/posts?page[size]=5"),
+		First:    link("/posts?page[number]=1&page[size]=5"),
+		Previous: null,
+		Next:     null,
+		Last:     link("/posts?page[number]=1&page[size]=5"),
+	}, req.PaginationLinks(0))
+
+	req = &Request{ResourceType: "posts", PageNumber: 3, PageSize: 5}
+	assert.Equal(t, &DocumentLinks{
+		Self:     link("/posts?page[number]=3&page[size]=5"),
+		First:    link("/posts?page[number]=1&page[size]=5"),
+		Previous: link("/posts?page[number]=2&page[size]=5"),
+		Next:     link("/posts?page[number]=4&page[size]=5"),
+	}, req.PaginationLinks(-1))
+
+	// offset and limit
+	req = &Request{ResourceType: "posts", PageOffset: 4, PageLimit: 3, Filters: map[string][]string{"title": {"foo"}}}
+	assert.Equal(t, &DocumentLinks{
+		Self:     link("/posts?filter[title]=foo&page[limit]=3&page[offset]=4"),
+		First:    link("/posts?filter[title]=foo&page[limit]=3"),
+		Previous: link("/posts?filter[title]=foo&page[limit]=3&page[offset]=1"),
+		Next:     link("/posts?filter[title]=foo&page[limit]=3&page[offset]=7"),
+		Last:     link("/posts?filter[title]=foo&page[limit]=3&page[offset]=7"),
+	}, req.PaginationLinks(10))
+
+	req = &Request{ResourceType: "posts", PageOffset: 5, PageLimit: 10}
+	assert.Equal(t, &DocumentLinks{
+		Self:     link("/posts?page[limit]=10&page[offset]=5"),
+		First:    link("/posts?page[limit]=10"),
+		Previous: link("/posts?page[limit]=10"),
+		Next:     null,
+		Last:     link("/posts?page[limit]=10&page[offset]=5"),
+	}, req.PaginationLinks(12))
+
+	req = &Request{ResourceType: "posts", PageOffset: 12, PageLimit: 3}
+	assert.Equal(t, &DocumentLinks{
+		Self:     link("/posts?page[limit]=3&page[offset]=12"),
+		First:    link("/posts?page[limit]=3"),
+		Previous: link("/posts?page[limit]=3&page[offset]=9"),
+		Next:     null,
+		Last:     link("/posts?page[limit]=3&page[offset]=9"),
+	}, req.PaginationLinks(10))
+
+	req = &Request{ResourceType: "posts", PageLimit: 3}
+	assert.Equal(t, &DocumentLinks{
+		Self:     link("/posts?page[limit]=3"),
+		First:    link("/posts?page[limit]=3"),
+		Previous: null,
+		Next:     null,
+		Last:     link("/posts?page[limit]=3"),
+	}, req.PaginationLinks(0))
+
+	req = &Request{ResourceType: "posts", PageOffset: 9, PageLimit: 3}
+	assert.Equal(t, &DocumentLinks{
+		Self:     link("/posts?page[limit]=3&page[offset]=9"),
+		First:    link("/posts?page[limit]=3"),
+		Previous: link("/posts?page[limit]=3&page[offset]=6"),
+		Next:     null,
+		Last:     link("/posts?page[limit]=3&page[offset]=9"),
+	}, req.PaginationLinks(10))
+
+	// cursor
+	req = &Request{ResourceType: "posts", PageSize: 2, PageAfter: "b", Include: []string{"author"}}
+	assert.Equal(t, &DocumentLinks{
+		Self:     link("/posts?include=author&page[after]=b&page[size]=2"),
+		First:    link("/posts?include=author&page[size]=2"),
+		Previous: link("/posts?include=author&page[before]=c&page[size]=2"),
+		Next:     null,
+	}, req.PaginationLinks(-1, "c", ""))
+
+	req = &Request{ResourceType: "posts", PageSize: 2}
+	assert.Equal(t, &DocumentLinks{
+		Self:     link("/posts?page[size]=2"),
+		First:    link("/posts?page[size]=2"),
+		Previous: null,
+		Next:     link("/posts?page[after]=d&page[size]=2"),
+	}, req.PaginationLinks(-1, "", "d"))
+}
+
+func TestPaginationMeta(t *testing.T) {
+	assert.Equal(t, Map{
+		"page": Map{
+			"total": int64(10),
+		},
+	}, PaginationMeta(10, false))
+
+	assert.Equal(t, Map{
+		"page": Map{
+			"rangeTruncated": true,
+		},
+	}, PaginationMeta(-1, true))
+}
+
+func TestParseRequestCursorPaginationErrors(t *testing.T) {
+	parser := &Parser{
+		MaxPageSize: 10,
+	}
+
+	req, err := parser.ParseRequest(newTestRequest("GET", "foo?page[size]=20"))
+	assert.Nil(t, req)
+	assert.Equal(t, BadRequestParam("page size exceeds maximum", "page[size]"), err)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "foo?page[size]=0"))
+	assert.Nil(t, req)
+	assert.Equal(t, BadRequestParam("invalid page size", "page[size]"), err)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "foo?page[size]=20&page[after]=a"))
+	assert.Nil(t, req)
+	assert.Equal(t, &Error{
+		Status: http.StatusBadRequest,
+		Title:  "bad request",
+		Detail: "page size exceeds maximum",
+		Links: &ErrorLinks{
+			Type: &Link{Href: "https://jsonapi.org/profiles/ethanresnick/cursor-pagination/max-size-exceeded"},
+		},
+		Source: &ErrorSource{
+			Parameter: "page[size]",
+		},
+		Meta: Map{
+			"page": Map{
+				"maxSize": int64(10),
+			},
+		},
+	}, err)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "foo?page[size]=0&page[before]=a"))
+	assert.Nil(t, req)
+	assert.Equal(t, CursorInvalidParameter("invalid page size", "page[size]"), err)
+
+	parser.Profiles = []string{CursorPaginationProfile}
+	r := newTestRequest("GET", "foo?page[size]=0")
+	r.Header.Set("Accept", MediaType+`; profile="`+CursorPaginationProfile+`"`)
+	req, err = parser.ParseRequest(r)
+	assert.Nil(t, req)
+	assert.Equal(t, &Error{
+		Status: http.StatusBadRequest,
+		Title:  "bad request",
+		Detail: "invalid page size",
+		Links: &ErrorLinks{
+			Type: &Link{Href: "https://jsonapi.org/profiles/ethanresnick/cursor-pagination/invalid-parameter"},
+		},
+		Source: &ErrorSource{
+			Parameter: "page[size]",
+		},
+	}, err)
+	parser.Profiles = nil
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "foo?page[after]="))
+	assert.Nil(t, req)
+	assert.Equal(t, CursorInvalidParameter("invalid page after", "page[after]"), err)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "foo?page[before]=a&page[after]=b"))
+	assert.Nil(t, req)
+	assert.Equal(t, &Error{
+		Status: http.StatusBadRequest,
+		Title:  "bad request",
+		Detail: "range pagination not supported",
+		Links: &ErrorLinks{
+			Type: &Link{Href: "https://jsonapi.org/profiles/ethanresnick/cursor-pagination/range-pagination-not-supported"},
+		},
+	}, err)
+
+	parser.RangePagination = true
+	req, err = parser.ParseRequest(newTestRequest("GET", "foo?page[before]=a&page[after]=b"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", req.PageBefore)
+	assert.Equal(t, "b", req.PageAfter)
+
+	assert.Equal(t, &Error{
+		Status: http.StatusBadRequest,
+		Title:  "bad request",
+		Detail: "unsupported sort field",
+		Links: &ErrorLinks{
+			Type: &Link{Href: "https://jsonapi.org/profiles/ethanresnick/cursor-pagination/unsupported-sort"},
+		},
+		Source: &ErrorSource{
+			Parameter: "sort",
+		},
+	}, CursorUnsupportedSort("unsupported sort field"))
+}
+
+func TestParseRequestDefaultPageSize(t *testing.T) {
+	parser := &Parser{
+		DefaultPageSize: 25,
+	}
+
+	req, err := parser.ParseRequest(newTestRequest("GET", "foo"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(25), req.PageSize)
+	assert.True(t, req.DefaultedPageSize)
+	assert.Equal(t, "/foo", req.Self())
+	assert.Equal(t, &DocumentLinks{
+		Self:     &Link{Href: "/foo"},
+		First:    &Link{Href: "/foo?" + escape("page[number]=1&page[size]=25")},
+		Last:     &Link{Href: "/foo?" + escape("page[number]=2&page[size]=25")},
+		Next:     &Link{Href: "/foo?" + escape("page[number]=2&page[size]=25")},
+		Previous: &Link{Href: NullLink},
+	}, req.PaginationLinks(50))
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "foo?page[size]=5"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), req.PageSize)
+	assert.False(t, req.DefaultedPageSize)
+	assert.Equal(t, "/foo?"+escape("page[size]=5"), req.Self())
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "foo?page[offset]=5&page[limit]=5"))
+	assert.NoError(t, err)
+	assert.Zero(t, req.PageSize)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "foo/1"))
+	assert.NoError(t, err)
+	assert.Zero(t, req.PageSize)
+}
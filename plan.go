@@ -0,0 +1,597 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// planField describes a single struct field in a field plan.
+type planField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+	fast      bool
+	wrapper   reflect.Type
+}
+
+// fieldPlan describes how the fields of a struct type are mapped to map keys.
+// It mirrors the field selection rules of the "encoding/json" package.
+type fieldPlan struct {
+	fields []*planField
+	names  map[string]*planField
+}
+
+var fieldPlans sync.Map
+
+// getFieldPlan will return the cached field plan for the passed struct type.
+func getFieldPlan(t reflect.Type) *fieldPlan {
+	// check cache
+	if value, ok := fieldPlans.Load(t); ok {
+		return value.(*fieldPlan)
+	}
+
+	// build plan
+	plan := buildFieldPlan(t)
+
+	// cache plan
+	value, _ := fieldPlans.LoadOrStore(t, plan)
+
+	return value.(*fieldPlan)
+}
+
+func buildFieldPlan(t reflect.Type) *fieldPlan {
+	type entry struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	// prepare queues
+	var current []entry
+	next := []entry{{typ: t}}
+
+	// prepare counters
+	var count map[reflect.Type]int
+	nextCount := map[reflect.Type]int{}
+
+	// prepare visited types
+	visited := map[reflect.Type]bool{}
+
+	// prepare fields
+	var fields []*planField
+
+	// walk embedded structs breadth first
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, e := range current {
+			// check visited
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				// get field
+				sf := e.typ.Field(i)
+
+				// get field type
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				// check visibility
+				if sf.Anonymous {
+					if sf.PkgPath != "" && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if sf.PkgPath != "" {
+					continue
+				}
+
+				// get tag
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+
+				// parse tag
+				name, opts := tag, ""
+				if i := strings.Index(tag, ","); i >= 0 {
+					name, opts = tag[:i], tag[i:]
+				}
+
+				// prepare index
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+
+				// follow untagged embedded structs
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					nextCount[ft]++
+					if nextCount[ft] == 1 {
+						next = append(next, entry{typ: ft, index: index})
+					}
+					continue
+				}
+
+				// prepare field
+				field := &planField{
+					name:      name,
+					index:     index,
+					tagged:    name != "",
+					omitEmpty: strings.Contains(opts, ",omitempty"),
+					fast:      isFastType(sf.Type),
+				}
+				if field.name == "" {
+					field.name = sf.Name
+				}
+
+				// check string option, quoted basic values are converted
+				// directly while other values are converted using a wrapper
+				// struct that carries the option
+				if strings.Contains(opts, ",string") {
+					switch ft.Kind() {
+					case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+						reflect.Float32, reflect.Float64, reflect.String:
+						if field.fast && ft == sf.Type && ft.Kind() != reflect.String {
+							field.quoted = true
+						} else {
+							field.fast = false
+							field.wrapper = reflect.StructOf([]reflect.StructField{{
+								Name: "V",
+								Type: sf.Type,
+								Tag:  `json:"v,string"`,
+							}})
+						}
+					}
+				}
+
+				// add field
+				fields = append(fields, field)
+
+				// add duplicate to annihilate the field if the struct has
+				// been embedded multiple times at the same level
+				if count[e.typ] > 1 {
+					fields = append(fields, field)
+				}
+			}
+		}
+	}
+
+	// sort fields by name, depth and tag
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		if fields[i].tagged != fields[j].tagged {
+			return fields[i].tagged
+		}
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+
+	// select dominant fields
+	plan := &fieldPlan{
+		names: map[string]*planField{},
+	}
+	for i := 0; i < len(fields); {
+		// find fields with the same name
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+
+		// drop ambiguous fields
+		if j-i == 1 || len(fields[i].index) != len(fields[i+1].index) || fields[i].tagged != fields[i+1].tagged {
+			plan.fields = append(plan.fields, fields[i])
+			plan.names[fields[i].name] = fields[i]
+		}
+
+		i = j
+	}
+
+	// sort fields by index
+	sort.Slice(plan.fields, func(i, j int) bool {
+		return lessIndex(plan.fields[i].index, plan.fields[j].index)
+	})
+
+	return plan
+}
+
+// lookup will return the field for the passed key. Like "encoding/json", an
+// exact match is preferred over a case-insensitive match.
+func (p *fieldPlan) lookup(key string) *planField {
+	// check exact match
+	if field, ok := p.names[key]; ok {
+		return field
+	}
+
+	// check case-insensitive match
+	for _, field := range p.fields {
+		if strings.EqualFold(field.name, key) {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// toMap will copy the fields of the passed struct value to a new map.
+func (p *fieldPlan) toMap(v reflect.Value) (Map, error) {
+	// prepare map
+	m := make(Map, len(p.fields))
+
+	for _, field := range p.fields {
+		// get field value
+		fv, ok := fieldByIndex(v, field.index, false)
+		if !ok {
+			continue
+		}
+
+		// check empty
+		if field.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+
+		// convert value
+		value, err := field.encode(fv)
+		if err != nil {
+			return nil, err
+		}
+
+		// set value
+		m[field.name] = value
+	}
+
+	return m, nil
+}
+
+// assign will assign the values of the passed map to the struct value. Like
+// "encoding/json" decoding the marshalled map, keys are processed in sorted
+// order and every matching key is assigned. Therefore, if multiple keys match
+// a field, the last one in sorted order wins.
+func (p *fieldPlan) assign(v reflect.Value, m Map) error {
+	// sort keys
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		// get field
+		field := p.lookup(key)
+		if field == nil {
+			continue
+		}
+		value := m[key]
+
+		// get field value
+		fv, ok := fieldByIndex(v, field.index, true)
+		if !ok {
+			return fmt.Errorf("json: cannot set embedded pointer to unexported struct for field %s", key)
+		}
+
+		// assign value
+		err := field.decode(fv, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encode will convert the passed field value.
+func (f *planField) encode(v reflect.Value) (interface{}, error) {
+	// use wrapper if available
+	if f.wrapper != nil {
+		return encodeWrapped(v, f.wrapper)
+	}
+
+	return encodeValue(v, f.fast, f.quoted)
+}
+
+// decode will assign the passed value to the field value.
+func (f *planField) decode(v reflect.Value, value interface{}) error {
+	// use wrapper if available
+	if f.wrapper != nil {
+		return decodeWrapped(v, value, f.wrapper)
+	}
+
+	return decodeValue(v, value, f.fast, f.quoted)
+}
+
+// fieldByIndex will return the nested field while optionally allocating nil
+// embedded pointers.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+// hasCustomMarshaller returns whether the type or its pointer implements one
+// of the passed interfaces.
+func hasCustomMarshaller(t reflect.Type, ifaces ...reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	for _, iface := range ifaces {
+		if t.Implements(iface) || pt.Implements(iface) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isFastType returns whether values of the passed type can be converted
+// directly without using "encoding/json".
+func isFastType(t reflect.Type) bool {
+	// check kind
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+	default:
+		return false
+	}
+
+	// check custom marshalling
+	return !hasCustomMarshaller(t, jsonMarshalerType, textMarshalerType, jsonUnmarshalerType, textUnmarshalerType)
+}
+
+// encodeValue will convert the passed value to the representation produced by
+// "encoding/json" when decoding into an empty interface using numbers.
+func encodeValue(v reflect.Value, fast, quoted bool) (interface{}, error) {
+	// use json if not fast
+	if !fast {
+		return encodeJSON(v)
+	}
+
+	// prepare string
+	var str string
+
+	// convert value
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		if !quoted {
+			return v.Bool(), nil
+		}
+		str = strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		str = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		str = strconv.FormatUint(v.Uint(), 10)
+	default:
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, &json.UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, v.Type().Bits())}
+		}
+		str = formatFloat(f, v.Type().Bits())
+	}
+
+	// handle quoted
+	if quoted {
+		return str, nil
+	}
+
+	return json.Number(str), nil
+}
+
+// encodeJSON will convert the passed value using "encoding/json".
+func encodeJSON(v reflect.Value) (interface{}, error) {
+	// use address to support pointer receivers
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+
+	// marshal value
+	buf, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare decoder
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+
+	// decode value
+	var value interface{}
+	err = dec.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// encodeWrapped will convert the passed value using "encoding/json" and the
+// passed single field wrapper struct to respect the field's tag options.
+func encodeWrapped(v reflect.Value, wrapper reflect.Type) (interface{}, error) {
+	// prepare wrapper
+	w := reflect.New(wrapper)
+	w.Elem().Field(0).Set(v)
+
+	// convert wrapper
+	value, err := encodeJSON(w)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(map[string]interface{})["v"], nil
+}
+
+// decodeValue will assign the passed value to the specified field value using
+// the same rules as "encoding/json".
+func decodeValue(v reflect.Value, value interface{}, fast, quoted bool) error {
+	// use json if not fast
+	if !fast {
+		return decodeJSON(v, value)
+	}
+
+	// handle null
+	if value == nil {
+		return nil
+	}
+
+	// handle quoted
+	if quoted {
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal %v into %v", value, v.Type())
+		}
+		if v.Kind() == reflect.Bool {
+			b, err := strconv.ParseBool(str)
+			if err != nil {
+				return fmt.Errorf("json: invalid use of ,string struct tag, trying to unmarshal %q into %v", str, v.Type())
+			}
+			v.SetBool(b)
+			return nil
+		}
+		value = json.Number(str)
+	}
+
+	// convert value
+	switch v.Kind() {
+	case reflect.String:
+		if str, ok := value.(string); ok {
+			v.SetString(str)
+			return nil
+		}
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			v.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if num, ok := value.(json.Number); ok {
+			n, err := strconv.ParseInt(string(num), 10, 64)
+			if err == nil && !v.OverflowInt(n) {
+				v.SetInt(n)
+				return nil
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if num, ok := value.(json.Number); ok {
+			n, err := strconv.ParseUint(string(num), 10, 64)
+			if err == nil && !v.OverflowUint(n) {
+				v.SetUint(n)
+				return nil
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		if num, ok := value.(json.Number); ok {
+			f, err := strconv.ParseFloat(string(num), v.Type().Bits())
+			if err == nil && !v.OverflowFloat(f) {
+				v.SetFloat(f)
+				return nil
+			}
+		}
+	}
+
+	// use json for all other values and to produce accurate errors
+	return decodeJSON(v, value)
+}
+
+// decodeJSON will assign the passed value to the field value using
+// "encoding/json".
+func decodeJSON(v reflect.Value, value interface{}) error {
+	// marshal value
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	// unmarshal value
+	return json.Unmarshal(buf, v.Addr().Interface())
+}
+
+// decodeWrapped will assign the passed value to the field value using
+// "encoding/json" and the passed single field wrapper struct to respect the
+// field's tag options.
+func decodeWrapped(v reflect.Value, value interface{}, wrapper reflect.Type) error {
+	// prepare wrapper
+	w := reflect.New(wrapper)
+	w.Elem().Field(0).Set(v)
+
+	// assign value
+	err := decodeJSON(w.Elem(), map[string]interface{}{"v": value})
+	if err != nil {
+		return err
+	}
+
+	// set value
+	v.Set(w.Elem().Field(0))
+
+	return nil
+}
+
+// formatFloat will format the float like "encoding/json".
+func formatFloat(f float64, bits int) string {
+	// select format
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	// format float
+	buf := strconv.AppendFloat(nil, f, format, -1, bits)
+
+	// clean up e-09 to e-9
+	if format == 'e' {
+		n := len(buf)
+		if n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+
+	return string(buf)
+}
+
+func lessIndex(a, b []int) bool {
+	for i, x := range a {
+		if i >= len(b) {
+			return false
+		}
+		if x != b[i] {
+			return x < b[i]
+		}
+	}
+
+	return len(a) < len(b)
+}
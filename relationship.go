@@ -0,0 +1,152 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// A Relationship describes a relationship between a resource and other JSON
+// API resources.
+//
+// See: https://jsonapi.org/format/#document-resource-object-relationships.
+type Relationship struct {
+	// The resource linkage in the form of a single resource identifier or a
+	// list of resource identifiers.
+	Data *HybridResource `json:"data,omitempty"`
+
+	// A set of links related to the relationship.
+	Links *DocumentLinks `json:"links,omitempty"`
+
+	// Non-standard meta-information about the relationship.
+	//
+	// Note: Numbers are left as strings to avoid issues with mismatching types
+	// when they are later assigned to a struct.
+	Meta Map `json:"meta,omitempty"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Null resource
+// linkage is decoded as empty data to distinguish it from missing linkage.
+func (r *Relationship) UnmarshalJSON(data []byte) error {
+	// prepare decoder
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// decode relationship
+	type relationship Relationship
+	err := dec.Decode((*relationship)(r))
+	if err != nil {
+		return err
+	}
+
+	// check data
+	if r.Data != nil {
+		return nil
+	}
+
+	// decode members
+	var members map[string]json.RawMessage
+	err = json.Unmarshal(data, &members)
+	if err != nil {
+		return err
+	}
+
+	// handle null data
+	if raw, ok := members["data"]; ok && (len(raw) == 0 || string(raw) == "null") {
+		r.Data = &HybridResource{}
+	}
+
+	return nil
+}
+
+// Relationship will return a relationship with the data, links and meta of the
+// document. It can be used to migrate code that used documents to describe
+// relationships.
+func (d *Document) Relationship() *Relationship {
+	return &Relationship{
+		Data:  d.Data,
+		Links: d.Links,
+		Meta:  d.Meta,
+	}
+}
+
+// Document will return a document with the data, links and meta of the
+// relationship. It can be used to write relationship responses.
+func (r *Relationship) Document() *Document {
+	return &Document{
+		Data:  r.Data,
+		Links: r.Links,
+		Meta:  r.Meta,
+	}
+}
+
+// ResourceLinks are a set of links related to a resource.
+//
+// See: https://jsonapi.org/format/#document-resource-object-links.
+type ResourceLinks struct {
+	Self *Link `json:"self,omitempty"`
+}
+
+// LinkResource will return a copy of the passed resource with the "self" link
+// and the "self" and "related" links of its relationships set using the prefix
+// of the request. The passed resource is not modified. Resources without an id
+// are returned as is.
+func (r *Request) LinkResource(res *Resource) *Resource {
+	// check id
+	if res.ID == "" {
+		return res
+	}
+
+	// prepare request
+	req := Request{
+		Prefix:       r.Prefix,
+		ResourceType: res.Type,
+		ResourceID:   res.ID,
+	}
+
+	// copy resource
+	cpy := *res
+
+	// set self link
+	cpy.Links = &ResourceLinks{}
+	if res.Links != nil {
+		*cpy.Links = *res.Links
+	}
+	cpy.Links.Self = &Link{Href: req.Path()}
+
+	// check relationships
+	if res.Relationships == nil {
+		return &cpy
+	}
+
+	// set relationship links
+	cpy.Relationships = make(map[string]*Relationship, len(res.Relationships))
+	for name, rel := range res.Relationships {
+		// check relationship
+		if rel == nil {
+			cpy.Relationships[name] = nil
+			continue
+		}
+
+		// copy relationship
+		relCpy := *rel
+		relCpy.Links = &DocumentLinks{}
+		if rel.Links != nil {
+			*relCpy.Links = *rel.Links
+		}
+
+		// set self link
+		self := req
+		self.Relationship = name
+		relCpy.Links.Self = &Link{Href: self.Path()}
+
+		// set related link
+		related := req
+		related.RelatedResource = name
+		relCpy.Links.Related = &Link{Href: related.Path()}
+
+		// set relationship
+		cpy.Relationships[name] = &relCpy
+	}
+
+	return &cpy
+}
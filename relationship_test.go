@@ -0,0 +1,131 @@
+package jsonapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDocumentRelationships(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(`{
+		"data": {
+			"type": "posts",
+			"id": "1",
+			"relationships": {
+				"author": {
+					"data": {
+						"type": "users",
+						"id": "1"
+					},
+					"links": {
+						"self": "/posts/1/relationships/author",
+						"related": "/posts/1/author"
+					},
+					"meta": {
+						"foo": "bar"
+					}
+				}
+			},
+			"links": {
+				"self": "/posts/1"
+			}
+		}
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &Resource{
+		Type: "posts",
+		ID:   "1",
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{
+					One: &Resource{Type: "users", ID: "1"},
+				},
+				Links: &DocumentLinks{
+					Self:    &Link{Href: "/posts/1/relationships/author"},
+					Related: &Link{Href: "/posts/1/author"},
+				},
+				Meta: Map{
+					"foo": "bar",
+				},
+			},
+		},
+		Links: &ResourceLinks{
+			Self: &Link{Href: "/posts/1"},
+		},
+	}, doc.Data.One)
+}
+
+func TestRelationshipDocument(t *testing.T) {
+	rel := &Relationship{
+		Data: &HybridResource{
+			One: &Resource{Type: "users", ID: "1"},
+		},
+		Links: &DocumentLinks{
+			Self: &Link{Href: "/posts/1/relationships/author"},
+		},
+		Meta: Map{
+			"foo": "bar",
+		},
+	}
+
+	doc := rel.Document()
+	assert.Equal(t, &Document{
+		Data:  rel.Data,
+		Links: rel.Links,
+		Meta:  rel.Meta,
+	}, doc)
+
+	doc.Included = []*Resource{{Type: "users", ID: "1"}}
+	assert.Equal(t, rel, doc.Relationship())
+}
+
+func TestRequestLinkResource(t *testing.T) {
+	req := &Request{Prefix: "api"}
+
+	res := &Resource{
+		Type: "posts",
+		ID:   "1",
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{},
+				Links: &DocumentLinks{
+					First: &Link{Href: "foo"},
+				},
+			},
+			"comments": {},
+		},
+	}
+	linked := req.LinkResource(res)
+	assert.Equal(t, &Resource{
+		Type: "posts",
+		ID:   "1",
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{},
+				Links: &DocumentLinks{
+					Self:    &Link{Href: "/api/posts/1/relationships/author"},
+					Related: &Link{Href: "/api/posts/1/author"},
+					First:   &Link{Href: "foo"},
+				},
+			},
+			"comments": {
+				Links: &DocumentLinks{
+					Self:    &Link{Href: "/api/posts/1/relationships/comments"},
+					Related: &Link{Href: "/api/posts/1/comments"},
+				},
+			},
+		},
+		Links: &ResourceLinks{
+			Self: &Link{Href: "/api/posts/1"},
+		},
+	}, linked)
+	assert.Nil(t, res.Links)
+	assert.Nil(t, res.Relationships["comments"].Links)
+	assert.Equal(t, &DocumentLinks{
+		First: &Link{Href: "foo"},
+	}, res.Relationships["author"].Links)
+
+	res = &Resource{Type: "posts"}
+	assert.Equal(t, res, req.LinkResource(res))
+}
@@ -69,6 +69,10 @@ const (
 	// PATCH /posts/1/settings
 	// DELETE /posts/1/history
 	ResourceAction
+
+	// AtomicOperations is a variation of the following request:
+	// POST /operations
+	AtomicOperations
 )
 
 // DocumentExpected returns whether a request using this intent is expected to
@@ -79,7 +83,7 @@ const (
 func (i Intent) DocumentExpected() bool {
 	switch i {
 	case CreateResource, UpdateResource, SetRelationship,
-		AppendToRelationship, RemoveFromRelationship:
+		AppendToRelationship, RemoveFromRelationship, AtomicOperations:
 		return true
 	}
 
@@ -91,7 +95,7 @@ func (i Intent) RequestMethod() string {
 	switch i {
 	case ListResources, FindResource, GetRelatedResources, GetRelationship:
 		return "GET"
-	case CreateResource, AppendToRelationship:
+	case CreateResource, AppendToRelationship, AtomicOperations:
 		return "POST"
 	case UpdateResource, SetRelationship:
 		return "PATCH"
@@ -121,6 +125,13 @@ type Request struct {
 	CollectionAction string
 	ResourceAction   string
 
+	// The extensions and profiles applied to the request. These are read from
+	// the "ext" and "profile" parameters of the content type header or of the
+	// preferred JSON API media type in the accept header if no content type is
+	// given. Unsupported profiles are ignored.
+	Extensions []string
+	Profiles   []string
+
 	// The requested resources to be included in the response. This is read
 	// from the "include" query parameter.
 	Include []string
@@ -137,6 +148,15 @@ type Request struct {
 	PageBefore string
 	PageAfter  string
 
+	// Whether the page size has been set from the default page size of the
+	// parser. A defaulted page size is omitted from the "self" link.
+	DefaultedPageSize bool
+
+	// The cursors decoded from the "page[before]" and "page[after]" query
+	// parameters if a cursor codec has been configured.
+	CursorBefore *Cursor
+	CursorAfter  *Cursor
+
 	// The pagination type that has been requested. This is read from the
 	// "pagination" query parameter. Possible values are "offset" or "cursor.
 	// This parameter does not belong to the standard.
@@ -155,9 +175,17 @@ type Request struct {
 	// recommended.
 	Filters map[string][]string
 
+	// The filter conditions that have been requested. These are read from the
+	// "filter" query parameters instead of Filters if the parser has been
+	// configured to parse filter conditions.
+	Conditions []Condition
+
 	// The search query that has been requested. This is read from the "search"
 	// query parameter. This parameter does not belong to the standard.
 	Search string
+
+	// The jsonapi object added to written documents that do not have one.
+	jsonapi *JSONAPI
 }
 
 // ParseRequest is a short-hand for Parser.ParseRequest and will be removed in
@@ -181,6 +209,54 @@ type Parser struct {
 	// Note: Make sure the actions do not contain "relationships" or use
 	// related resource types.
 	ResourceActions map[string][]string
+
+	// Whether documents parsed using ParseDocument should be validated.
+	StrictDocuments bool
+
+	// The endpoint that accepts atomic operations e.g. "operations". The
+	// endpoint is stored as the resource type of the request.
+	//
+	// Note: Make sure the endpoint does not conflict with a resource type.
+	AtomicEndpoint string
+
+	// The supported extensions. Requests that use other extensions are
+	// rejected. The atomic operations extension is supported implicitly if an
+	// endpoint is configured.
+	Extensions []string
+
+	// The supported profiles. Other requested profiles are ignored.
+	Profiles []string
+
+	// The maximum page size. Requests with a larger "page[size]" parameter are
+	// rejected. CursorMaxSizeExceeded is used if the request uses cursor
+	// pagination or has negotiated the cursor pagination profile.
+	MaxPageSize int64
+
+	// The page size set on list requests that do not use offset pagination and
+	// have no "page[size]" parameter.
+	DefaultPageSize int64
+
+	// Whether requests may use both the "page[before]" and "page[after]"
+	// parameter. Otherwise, they are rejected using
+	// CursorRangePaginationNotSupported.
+	RangePagination bool
+
+	// The codec used to decode the "page[before]" and "page[after]" parameters
+	// into cursors. The sorting of the cursors must match the request.
+	CursorCodec *CursorCodec
+
+	// Whether "filter" query parameters should be parsed into conditions
+	// using ParseCondition instead of being collected as raw filters.
+	FilterConditions bool
+
+	// The query parameter schemas of the resource types. Requests are
+	// validated using the schema registered for their resource type and the
+	// schemas of the types in sparse fieldsets.
+	Schemas map[string]*Schema
+
+	// The jsonapi object that is added to documents without one when they are
+	// written using the methods of the parsed requests e.g. WriteResource.
+	JSONAPI *JSONAPI
 }
 
 // ParseRequest will parse the passed request and return a new Request with the
@@ -197,7 +273,8 @@ func (p *Parser) ParseRequest(r *http.Request) (*Request, error) {
 
 	// allocate new request
 	req := &Request{
-		Prefix: strings.Trim(p.Prefix, "/"),
+		Prefix:  strings.Trim(p.Prefix, "/"),
+		jsonapi: p.JSONAPI,
 	}
 
 	// de-prefix and trim path
@@ -285,7 +362,11 @@ func (p *Parser) ParseRequest(r *http.Request) (*Request, error) {
 	case "POST":
 		switch level {
 		case 1:
-			req.Intent = CreateResource
+			if p.AtomicEndpoint != "" && req.ResourceType == p.AtomicEndpoint {
+				req.Intent = AtomicOperations
+			} else {
+				req.Intent = CreateResource
+			}
 		case 4:
 			req.Intent = AppendToRelationship
 		}
@@ -312,16 +393,9 @@ func (p *Parser) ParseRequest(r *http.Request) (*Request, error) {
 
 	// check headers for standard requests
 	if req.Intent != CollectionAction && req.Intent != ResourceAction {
-		// check content type header
-		contentType := r.Header.Get("Content-Type")
-		if contentType != "" && contentType != MediaType {
-			return nil, BadRequest("invalid content type header")
-		}
-
-		// check accept header
-		accept := r.Header.Get("Accept")
-		if accept != "" && accept != "*/*" && accept != "application/*" && accept != "application/json" && accept != MediaType {
-			return nil, ErrorFromStatus(http.StatusNotAcceptable, "invalid accept header")
+		err := p.negotiate(r, req)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -330,8 +404,25 @@ func (p *Parser) ParseRequest(r *http.Request) (*Request, error) {
 		return nil, BadRequest("missing content type header")
 	}
 
+	// get query
+	query := r.URL.Query()
+
+	// check if cursor pagination is used, page size errors are only reported
+	// using the cursor pagination profile in that case
+	_, before := query["page[before]"]
+	_, after := query["page[after]"]
+	cursor := before || after || contains(req.Profiles, CursorPaginationProfile)
+
 	// handle query parameters
-	for key, values := range r.URL.Query() {
+	for _, key := range sortedParams(query) {
+		// get values
+		values := query[key]
+
+		// check unknown parameters
+		if !knownParameter(key) {
+			return nil, BadRequestParam("unsupported query parameter", key)
+		}
+
 		// set included resources
 		if key == "include" {
 			for _, v := range values {
@@ -364,6 +455,17 @@ func (p *Parser) ParseRequest(r *http.Request) (*Request, error) {
 
 		// set filters
 		if strings.HasPrefix(key, "filter[") && strings.HasSuffix(key, "]") {
+			if p.FilterConditions {
+				for _, v := range values {
+					cond, err := ParseCondition(key, v)
+					if err != nil {
+						return nil, err
+					}
+					req.Conditions = append(req.Conditions, *cond)
+				}
+				continue
+			}
+
 			if req.Filters == nil {
 				req.Filters = make(map[string][]string)
 			}
@@ -394,9 +496,16 @@ func (p *Parser) ParseRequest(r *http.Request) (*Request, error) {
 		// set page size
 		if key == "page[size]" {
 			n, err := strconv.ParseInt(values[0], 10, 0)
-			if err != nil {
+			if (err != nil || n <= 0) && cursor {
+				return nil, CursorInvalidParameter("invalid page size", "page[size]")
+			} else if err != nil || n <= 0 {
 				return nil, BadRequestParam("invalid page size", "page[size]")
 			}
+			if p.MaxPageSize > 0 && n > p.MaxPageSize && cursor {
+				return nil, CursorMaxSizeExceeded(p.MaxPageSize)
+			} else if p.MaxPageSize > 0 && n > p.MaxPageSize {
+				return nil, BadRequestParam("page size exceeds maximum", "page[size]")
+			}
 			req.PageSize = n
 			continue
 		}
@@ -423,12 +532,18 @@ func (p *Parser) ParseRequest(r *http.Request) (*Request, error) {
 
 		// set page before
 		if key == "page[before]" {
+			if values[0] == "" {
+				return nil, CursorInvalidParameter("invalid page before", "page[before]")
+			}
 			req.PageBefore = values[0]
 			continue
 		}
 
 		// set page after
 		if key == "page[after]" {
+			if values[0] == "" {
+				return nil, CursorInvalidParameter("invalid page after", "page[after]")
+			}
 			req.PageAfter = values[0]
 			continue
 		}
@@ -445,6 +560,44 @@ func (p *Parser) ParseRequest(r *http.Request) (*Request, error) {
 		}
 	}
 
+	// sort conditions
+	sortConditions(req.Conditions)
+
+	// validate schemas
+	if p.Schemas != nil {
+		err := p.validateSchemas(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// set default page size
+	if p.DefaultPageSize > 0 && req.PageSize == 0 && req.PageOffset == 0 && req.PageLimit == 0 {
+		switch req.Intent {
+		case ListResources, GetRelatedResources, GetRelationship:
+			req.PageSize = p.DefaultPageSize
+			req.DefaultedPageSize = true
+		}
+	}
+
+	// check range pagination
+	if req.PageBefore != "" && req.PageAfter != "" && !p.RangePagination {
+		return nil, CursorRangePaginationNotSupported()
+	}
+
+	// decode cursors
+	if p.CursorCodec != nil {
+		var err error
+		req.CursorBefore, err = p.decodeCursor(req, req.PageBefore, "page[before]")
+		if err != nil {
+			return nil, err
+		}
+		req.CursorAfter, err = p.decodeCursor(req, req.PageAfter, "page[after]")
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// check that page size is set if page number is set
 	if req.PageNumber > 0 && req.PageSize <= 0 {
 		return nil, BadRequestParam("missing page size", "page[number]")
@@ -460,6 +613,26 @@ func (p *Parser) ParseRequest(r *http.Request) (*Request, error) {
 	return req, nil
 }
 
+func (p *Parser) decodeCursor(req *Request, token, param string) (*Cursor, error) {
+	// check token
+	if token == "" {
+		return nil, nil
+	}
+
+	// decode cursor
+	cursor, err := p.CursorCodec.Decode(token)
+	if err != nil {
+		return nil, CursorInvalidParameter("invalid cursor", param)
+	}
+
+	// check sorting
+	if strings.Join(cursor.Sorting, ",") != strings.Join(req.Sorting, ",") {
+		return nil, CursorInvalidParameter("cursor sorting mismatch", param)
+	}
+
+	return cursor, nil
+}
+
 // Base will generate the base path for this request, which includes the type
 // and id if present.
 func (r *Request) Base() string {
@@ -568,6 +741,11 @@ func (r *Request) Query() url.Values {
 		}
 	}
 
+	// add conditions
+	for _, cond := range r.Conditions {
+		values.Add(cond.Param(), cond.Value())
+	}
+
 	// add search
 	if r.Search != "" {
 		values.Set("search", r.Search)
@@ -581,7 +759,15 @@ func (r *Request) Query() url.Values {
 func (r *Request) Self() string {
 	// get path and query
 	path := r.Path()
-	query := r.Query().Encode()
+	values := r.Query()
+
+	// remove defaulted page size
+	if r.DefaultedPageSize {
+		values.Del("page[size]")
+	}
+
+	// encode query
+	query := values.Encode()
 
 	// apply query if present
 	if query != "" {
@@ -636,6 +822,14 @@ func (r Request) Merge(reqs ...Request) Request {
 			r.ResourceAction = rq.ResourceAction
 		}
 
+		// check extensions and profiles
+		if len(rq.Extensions) != 0 {
+			r.Extensions = mergeList(r.Extensions, rq.Extensions)
+		}
+		if len(rq.Profiles) != 0 {
+			r.Profiles = mergeList(r.Profiles, rq.Profiles)
+		}
+
 		// check include
 		if len(rq.Include) != 0 {
 			r.Include = append(r.Include, rq.Include...)
@@ -660,6 +854,12 @@ func (r Request) Merge(reqs ...Request) Request {
 		if rq.PageAfter != "" {
 			r.PageAfter = rq.PageAfter
 		}
+		if rq.CursorBefore != nil {
+			r.CursorBefore = rq.CursorBefore
+		}
+		if rq.CursorAfter != nil {
+			r.CursorAfter = rq.CursorAfter
+		}
 		if rq.Pagination != "" {
 			r.Pagination = rq.Pagination
 		}
@@ -697,6 +897,11 @@ func (r Request) Merge(reqs ...Request) Request {
 			}
 		}
 
+		// check conditions
+		if len(rq.Conditions) > 0 {
+			r.Conditions = append(r.Conditions, rq.Conditions...)
+		}
+
 		// check search
 		if rq.Search != "" {
 			r.Search = rq.Search
@@ -307,6 +307,39 @@ func TestParseRequestActions(t *testing.T) {
 	}
 }
 
+func TestParseRequestAtomicOperations(t *testing.T) {
+	parser := &Parser{
+		Prefix:         "api",
+		AtomicEndpoint: "operations",
+	}
+
+	r := newTestRequest("POST", "/api/operations")
+	r.Header.Set("Content-Type", atomicMediaType)
+	r.Header.Set("Accept", atomicMediaType)
+
+	req, err := parser.ParseRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, AtomicOperations, req.Intent)
+	assert.True(t, req.Intent.DocumentExpected())
+	assert.Equal(t, "POST", req.Intent.RequestMethod())
+	assert.Equal(t, "/api/operations", req.Path())
+
+	r = newTestRequest("POST", "/api/posts")
+	r.Header.Set("Content-Type", atomicMediaType)
+
+	req, err = (&Parser{Prefix: "api"}).ParseRequest(r)
+	assert.Error(t, err)
+	assert.Equal(t, "unsupported media type: unsupported extension", err.Error())
+	assert.Nil(t, req)
+
+	r = newTestRequest("POST", "/api/operations")
+	r.Header.Set("Content-Type", MediaType)
+
+	req, err = (&Parser{Prefix: "api"}).ParseRequest(r)
+	assert.NoError(t, err)
+	assert.Equal(t, CreateResource, req.Intent)
+}
+
 func TestParseRequestInclude(t *testing.T) {
 	r1 := newTestRequest("GET", "foo?include=bar,baz")
 
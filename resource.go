@@ -26,6 +26,13 @@ type Resource struct {
 	// the server.
 	ID string `json:"id,omitempty"`
 
+	// The local id of the resource. It may be set instead of the id to
+	// identify a resource that is created on the server within the same
+	// document or atomic operations request.
+	//
+	// See: https://jsonapi.org/format/1.1/#document-resource-object-identification.
+	LID string `json:"lid,omitempty"`
+
 	// An attributes map representing some of the resource's data.
 	//
 	// Note: Numbers are left as strings to avoid issues with mismatching types
@@ -34,7 +41,10 @@ type Resource struct {
 
 	// A relationships object describing relationships between the resource and
 	// other JSON API resources.
-	Relationships map[string]*Document `json:"relationships,omitempty"`
+	Relationships map[string]*Relationship `json:"relationships,omitempty"`
+
+	// A set of links related to the resource.
+	Links *ResourceLinks `json:"links,omitempty"`
 
 	// Non-standard meta-information about the resource.
 	//
@@ -83,6 +93,9 @@ func (r *HybridResource) UnmarshalJSON(doc []byte) error {
 
 // WriteResource will wrap the passed resource, links and included resources in
 // a document and write it to the passed response writer.
+//
+// Note: The included resources are written as is. Use a DocumentBuilder to
+// deduplicate them.
 func WriteResource(w http.ResponseWriter, status int, resource *Resource, links *DocumentLinks, included ...*Resource) error {
 	return WriteResponse(w, status, &Document{
 		Data: &HybridResource{
@@ -95,6 +108,9 @@ func WriteResource(w http.ResponseWriter, status int, resource *Resource, links
 
 // WriteResources will wrap the passed resources, links and included resources
 // in a document and write it to the passed response writer.
+//
+// Note: The included resources are written as is. Use a DocumentBuilder to
+// deduplicate them.
 func WriteResources(w http.ResponseWriter, status int, resources []*Resource, links *DocumentLinks, included ...*Resource) error {
 	return WriteResponse(w, status, &Document{
 		Data: &HybridResource{
@@ -102,7 +102,7 @@ func TestWriteResourceRelationship(t *testing.T) {
 	err := WriteResource(res, http.StatusOK, &Resource{
 		Type: "foo",
 		ID:   "1",
-		Relationships: map[string]*Document{
+		Relationships: map[string]*Relationship{
 			"bar": {
 				Data: &HybridResource{
 					One: &Resource{
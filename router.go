@@ -0,0 +1,303 @@
+package jsonapi
+
+import (
+	"net/http"
+	"strings"
+)
+
+// A Lister handles ListResources requests.
+type Lister interface {
+	List(req *Request) (*Document, error)
+}
+
+// A Finder handles FindResource requests.
+type Finder interface {
+	Find(req *Request) (*Document, error)
+}
+
+// A Creator handles CreateResource requests.
+type Creator interface {
+	Create(req *Request, doc *Document) (*Document, error)
+}
+
+// An Updater handles UpdateResource requests. A nil document results in a
+// "No Content" response.
+type Updater interface {
+	Update(req *Request, doc *Document) (*Document, error)
+}
+
+// A Deleter handles DeleteResource requests.
+type Deleter interface {
+	Delete(req *Request) error
+}
+
+// A RelationshipGetter handles GetRelatedResources and GetRelationship
+// requests.
+type RelationshipGetter interface {
+	GetRelationship(req *Request) (*Document, error)
+}
+
+// A RelationshipSetter handles SetRelationship, AppendToRelationship and
+// RemoveFromRelationship requests. A nil document results in a "No Content"
+// response.
+type RelationshipSetter interface {
+	SetRelationship(req *Request, doc *Document) (*Document, error)
+}
+
+// An AtomicOperator handles AtomicOperations requests. It is registered using
+// the atomic endpoint of the parser as the resource type.
+type AtomicOperator interface {
+	Operate(req *Request, doc *Document) (*Document, error)
+}
+
+// A CollectionActionHandler handles CollectionAction requests. The actions
+// and their allowed methods are only available for the registered type.
+type CollectionActionHandler interface {
+	CollectionActions() map[string][]string
+	HandleCollectionAction(req *Request, w http.ResponseWriter, r *http.Request) error
+}
+
+// A ResourceActionHandler handles ResourceAction requests. The actions and
+// their allowed methods are only available for the registered type.
+type ResourceActionHandler interface {
+	ResourceActions() map[string][]string
+	HandleResourceAction(req *Request, w http.ResponseWriter, r *http.Request) error
+}
+
+// A Router dispatches requests to the controllers registered for their
+// resource type. A controller implements one or more of the handler
+// interfaces e.g. Lister or Finder. Requests for unknown types are rejected
+// with a "Not Found" error and requests with methods that are not handled by
+// the controller with a "Method Not Allowed" error and an "Allow" header.
+// Atomic operations requests are dispatched to the AtomicOperator registered
+// for the atomic endpoint.
+type Router struct {
+	parser            *Parser
+	controllers       map[string]interface{}
+	collectionActions map[string]map[string][]string
+	resourceActions   map[string]map[string][]string
+}
+
+// NewRouter will create and return a new router that uses the passed parser.
+func NewRouter(parser *Parser) *Router {
+	return &Router{
+		parser:            parser,
+		controllers:       map[string]interface{}{},
+		collectionActions: map[string]map[string][]string{},
+		resourceActions:   map[string]map[string][]string{},
+	}
+}
+
+// Register will register the controller for the passed resource type.
+//
+// Note: The actions of the controller are merged with the actions of the
+// parser for requests of the passed type only. The parser is not modified.
+func (rt *Router) Register(typ string, controller interface{}) {
+	// store collection actions
+	if handler, ok := controller.(CollectionActionHandler); ok {
+		rt.collectionActions[typ] = mergeActions(nil, handler.CollectionActions())
+	}
+
+	// store resource actions
+	if handler, ok := controller.(ResourceActionHandler); ok {
+		rt.resourceActions[typ] = mergeActions(nil, handler.ResourceActions())
+	}
+
+	// store controller
+	rt.controllers[typ] = controller
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := rt.serve(w, r)
+	if err != nil {
+		_ = WriteError(w, err)
+	}
+}
+
+func (rt *Router) serve(w http.ResponseWriter, r *http.Request) error {
+	// get controller
+	typ := rt.resourceType(r)
+	controller := rt.controllers[typ]
+	if controller == nil {
+		return NotFound("unknown resource type")
+	}
+
+	// get parser
+	parser := rt.parserFor(typ)
+
+	// parse request
+	req, err := parser.ParseRequest(r)
+	if err != nil {
+		if allowed := rt.allowed(parser, controller, r); len(allowed) > 0 && !contains(allowed, r.Method) {
+			return rt.notAllowed(w, allowed)
+		}
+		return err
+	}
+
+	// check controller
+	if !rt.handles(controller, req, r.Method) {
+		return rt.notAllowed(w, rt.allowed(parser, controller, r))
+	}
+
+	// handle actions
+	switch req.Intent {
+	case CollectionAction:
+		return controller.(CollectionActionHandler).HandleCollectionAction(req, w, r)
+	case ResourceAction:
+		return controller.(ResourceActionHandler).HandleResourceAction(req, w, r)
+	}
+
+	// echo applied extensions and profiles
+	w.Header().Set("Content-Type", req.MediaType())
+
+	// parse document
+	var doc *Document
+	if req.Intent.DocumentExpected() {
+		doc, err = parser.ParseDocument(r.Body)
+		if err != nil {
+			return err
+		}
+	}
+
+	// handle intent
+	status := http.StatusOK
+	switch req.Intent {
+	case ListResources:
+		doc, err = controller.(Lister).List(req)
+	case FindResource:
+		doc, err = controller.(Finder).Find(req)
+	case CreateResource:
+		doc, err = controller.(Creator).Create(req, doc)
+		status = http.StatusCreated
+	case UpdateResource:
+		doc, err = controller.(Updater).Update(req, doc)
+	case DeleteResource:
+		doc, err = nil, controller.(Deleter).Delete(req)
+	case GetRelatedResources, GetRelationship:
+		doc, err = controller.(RelationshipGetter).GetRelationship(req)
+	case SetRelationship, AppendToRelationship, RemoveFromRelationship:
+		doc, err = controller.(RelationshipSetter).SetRelationship(req, doc)
+	case AtomicOperations:
+		doc, err = controller.(AtomicOperator).Operate(req, doc)
+	}
+	if err != nil {
+		return err
+	}
+
+	// handle empty responses
+	if doc == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	return req.write(w, status, doc)
+}
+
+func (rt *Router) handles(controller interface{}, req *Request, method string) bool {
+	// check intent
+	var ok bool
+	switch req.Intent {
+	case ListResources:
+		_, ok = controller.(Lister)
+	case FindResource:
+		_, ok = controller.(Finder)
+	case CreateResource:
+		_, ok = controller.(Creator)
+	case UpdateResource:
+		_, ok = controller.(Updater)
+	case DeleteResource:
+		_, ok = controller.(Deleter)
+	case GetRelatedResources, GetRelationship:
+		_, ok = controller.(RelationshipGetter)
+	case SetRelationship, AppendToRelationship, RemoveFromRelationship:
+		_, ok = controller.(RelationshipSetter)
+	case AtomicOperations:
+		_, ok = controller.(AtomicOperator)
+	case CollectionAction:
+		var handler CollectionActionHandler
+		handler, ok = controller.(CollectionActionHandler)
+		ok = ok && contains(handler.CollectionActions()[req.CollectionAction], method)
+	case ResourceAction:
+		var handler ResourceActionHandler
+		handler, ok = controller.(ResourceActionHandler)
+		ok = ok && contains(handler.ResourceActions()[req.ResourceAction], method)
+	}
+
+	return ok
+}
+
+// parserFor will return a copy of the parser that includes the actions
+// registered for the passed type.
+func (rt *Router) parserFor(typ string) *Parser {
+	// copy parser
+	parser := *rt.parser
+
+	// merge actions
+	if actions := rt.collectionActions[typ]; actions != nil {
+		parser.CollectionActions = mergeActions(mergeActions(nil, rt.parser.CollectionActions), actions)
+	}
+	if actions := rt.resourceActions[typ]; actions != nil {
+		parser.ResourceActions = mergeActions(mergeActions(nil, rt.parser.ResourceActions), actions)
+	}
+
+	return &parser
+}
+
+// allowed will return the methods that are handled for the URL of the request.
+func (rt *Router) allowed(parser *Parser, controller interface{}, r *http.Request) []string {
+	// check methods
+	var list []string
+	for _, method := range []string{"GET", "POST", "PATCH", "DELETE"} {
+		// prepare request
+		probe, err := http.NewRequest(method, r.URL.Path, nil)
+		if err != nil {
+			continue
+		}
+		probe.Header.Set("Content-Type", MediaType)
+
+		// parse request
+		req, err := parser.ParseRequest(probe)
+		if err != nil {
+			continue
+		}
+
+		// check controller
+		if rt.handles(controller, req, method) {
+			list = append(list, method)
+		}
+	}
+
+	return list
+}
+
+func (rt *Router) notAllowed(w http.ResponseWriter, allowed []string) error {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	return ErrorFromStatus(http.StatusMethodNotAllowed, "method not allowed")
+}
+
+func (rt *Router) resourceType(r *http.Request) string {
+	// get location
+	prefix := strings.Trim(rt.parser.Prefix, "/")
+	location := strings.TrimPrefix(strings.Trim(r.URL.Path, "/"), prefix+"/")
+
+	return strings.Split(location, "/")[0]
+}
+
+func mergeActions(actions, other map[string][]string) map[string][]string {
+	// ensure map
+	if actions == nil {
+		actions = map[string][]string{}
+	}
+
+	// add methods
+	for name, methods := range other {
+		for _, method := range methods {
+			if !contains(actions[name], method) {
+				actions[name] = append(actions[name], method)
+			}
+		}
+	}
+
+	return actions
+}
@@ -0,0 +1,234 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testController struct {
+	deleted string
+}
+
+func (c *testController) List(req *Request) (*Document, error) {
+	return &Document{
+		Data: &HybridResource{
+			Many: []*Resource{
+				{Type: "posts", ID: "1", Attributes: Map{"title": "foo", "body": "bar"}},
+			},
+		},
+	}, nil
+}
+
+func (c *testController) Find(req *Request) (*Document, error) {
+	if req.ResourceID != "1" {
+		return nil, NotFound("unknown resource")
+	}
+
+	return &Document{
+		Data: &HybridResource{
+			One: &Resource{Type: "posts", ID: "1"},
+		},
+	}, nil
+}
+
+func (c *testController) Create(req *Request, doc *Document) (*Document, error) {
+	doc.Data.One.ID = "2"
+	return doc, nil
+}
+
+func (c *testController) Delete(req *Request) error {
+	c.deleted = req.ResourceID
+	return nil
+}
+
+func (c *testController) ResourceActions() map[string][]string {
+	return map[string][]string{
+		"publish": {"POST"},
+	}
+}
+
+func (c *testController) HandleResourceAction(req *Request, w http.ResponseWriter, r *http.Request) error {
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+func TestRouter(t *testing.T) {
+	controller := &testController{}
+
+	router := NewRouter(&Parser{Prefix: "api"})
+	router.Register("posts", controller)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			r.Header.Set("Content-Type", MediaType)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := serve("GET", "/api/posts?fields[posts]=title", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MediaType, rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"data": [
+			{
+				"type": "posts",
+				"id": "1",
+				"attributes": {
+					"title": "foo"
+				}
+			}
+		]
+	}`, rec.Body.String())
+
+	rec = serve("GET", "/api/posts/2", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serve("POST", "/api/posts", `{"data":{"type":"posts"}}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, `{
+		"data": {
+			"type": "posts",
+			"id": "2"
+		}
+	}`, rec.Body.String())
+
+	rec = serve("POST", "/api/posts", `{`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve("DELETE", "/api/posts/1", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "1", controller.deleted)
+
+	rec = serve("POST", "/api/posts/1/publish", "")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	rec = serve("PATCH", "/api/posts/1", `{"data":{"type":"posts","id":"1"}}`)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, DELETE", rec.Header().Get("Allow"))
+	assert.JSONEq(t, `{
+		"errors": [{
+			"status": "405",
+			"title": "method not allowed",
+			"detail": "method not allowed"
+		}]
+	}`, rec.Body.String())
+
+	rec = serve("PUT", "/api/posts", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, POST", rec.Header().Get("Allow"))
+
+	rec = serve("POST", "/api/posts/1", `{"data":{"type":"posts"}}`)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, DELETE", rec.Header().Get("Allow"))
+
+	rec = serve("GET", "/api/posts/1/relationships/author", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "", rec.Header().Get("Allow"))
+
+	rec = serve("GET", "/api/posts?page[number]=foo", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serve("GET", "/api/comments", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{
+		"errors": [{
+			"status": "404",
+			"title": "not found",
+			"detail": "unknown resource type"
+		}]
+	}`, rec.Body.String())
+}
+
+type testOperator struct{}
+
+func (o *testOperator) Operate(req *Request, doc *Document) (*Document, error) {
+	results := make([]*Result, 0, len(doc.Operations))
+	for range doc.Operations {
+		results = append(results, &Result{})
+	}
+
+	return &Document{
+		Results: results,
+	}, nil
+}
+
+func TestRouterPerTypeActions(t *testing.T) {
+	parser := &Parser{AtomicEndpoint: "operations"}
+
+	router := NewRouter(parser)
+	router.Register("posts", &testController{})
+	router.Register("operations", &testOperator{})
+	assert.Nil(t, parser.ResourceActions)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			r.Header.Set("Content-Type", MediaType)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, r)
+		return rec
+	}
+
+	rec := serve("POST", "/posts/1/publish", "")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	router.Register("tags", &struct{ Finder }{&testController{}})
+
+	rec = serve("POST", "/tags/1/publish", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	r := httptest.NewRequest("POST", "/operations", strings.NewReader(`{"atomic:operations":[{"op":"remove","ref":{"type":"posts","id":"1"}}]}`))
+	r.Header.Set("Content-Type", MediaType+`; ext="`+AtomicExtension+`"`)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"atomic:results": [{}]
+	}`, rec.Body.String())
+}
+
+func TestRouterJSONAPI(t *testing.T) {
+	parser := &Parser{
+		JSONAPI: &JSONAPI{Version: "1.1"},
+	}
+
+	router := NewRouter(parser)
+	router.Register("posts", &testController{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/posts/1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"jsonapi": {
+			"version": "1.1"
+		},
+		"data": {
+			"type": "posts",
+			"id": "1"
+		}
+	}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/posts/2", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	parser.JSONAPI = nil
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/posts/1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"data": {
+			"type": "posts",
+			"id": "1"
+		}
+	}`, rec.Body.String())
+}
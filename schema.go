@@ -0,0 +1,240 @@
+package jsonapi
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A FilterType describes the type of the values of a filterable field.
+type FilterType string
+
+// The available filter types.
+const (
+	StringFilter  FilterType = "string"
+	NumberFilter  FilterType = "number"
+	BooleanFilter FilterType = "boolean"
+	TimeFilter    FilterType = "time"
+)
+
+// valid returns whether the value is valid for the type.
+func (t FilterType) valid(value string) bool {
+	switch t {
+	case NumberFilter:
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case BooleanFilter:
+		return value == "true" || value == "false"
+	case TimeFilter:
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	}
+
+	return true
+}
+
+// supports returns whether the operator can be used with the type.
+func (t FilterType) supports(op FilterOperator) bool {
+	switch op {
+	case LikeOperator:
+		return t == StringFilter
+	case GreaterThanOperator, GreaterThanOrEqualOperator, LessThanOperator,
+		LessThanOrEqualOperator:
+		return t != BooleanFilter
+	}
+
+	return true
+}
+
+// A Schema describes the query parameters accepted for a resource type.
+type Schema struct {
+	// The fields that may be used for sorting.
+	Sorting []string
+
+	// The relationship paths that may be included e.g. "comments.author".
+	// Parent paths of listed paths may be included as well.
+	Include []string
+
+	// The maximum number of segments of an include path. Zero means no limit.
+	MaxIncludeDepth int
+
+	// The attributes and relationships that may be requested using sparse
+	// fieldsets.
+	Fields []string
+
+	// The fields that may be filtered and the type of their values.
+	Filters map[string]FilterType
+
+	// The resource types of the relationships. The sorting and include of
+	// requests for related resources are validated using the schema of the
+	// related type.
+	Relationships map[string]string
+}
+
+// validate will validate the query parameters of the request.
+func (s *Schema) validate(req *Request) error {
+	// check sorting and include
+	err := s.validateSortingAndInclude(req)
+	if err != nil {
+		return err
+	}
+
+	// check raw filters
+	for _, name := range sortedParams(req.Filters) {
+		values := req.Filters[name]
+		typ, ok := s.Filters[name]
+		if !ok {
+			return BadRequestParam("invalid filter field", "filter["+name+"]")
+		}
+		for _, value := range values {
+			if !typ.valid(value) {
+				return BadRequestParam("invalid filter value", "filter["+name+"]")
+			}
+		}
+	}
+
+	// check conditions
+	for _, cond := range req.Conditions {
+		typ, ok := s.Filters[cond.Field()]
+		if !ok {
+			return BadRequestParam("invalid filter field", cond.Param())
+		}
+		if !typ.supports(cond.Operator) {
+			return BadRequestParam("unsupported filter operator", cond.Param())
+		}
+		if cond.Operator != LikeOperator {
+			for _, value := range cond.Values {
+				if !typ.valid(value) {
+					return BadRequestParam("invalid filter value", cond.Param())
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateSortingAndInclude will validate the sorting and include of the
+// request.
+func (s *Schema) validateSortingAndInclude(req *Request) error {
+	// check sorting
+	for _, field := range req.Sorting {
+		if !contains(s.Sorting, strings.TrimPrefix(field, "-")) {
+			return BadRequestParam("invalid sort field", "sort")
+		}
+	}
+
+	// check include
+	for _, path := range req.Include {
+		if s.MaxIncludeDepth > 0 && strings.Count(path, ".") >= s.MaxIncludeDepth {
+			return BadRequestParam("include path too deep", "include")
+		}
+		if !s.includes(path) {
+			return BadRequestParam("invalid include path", "include")
+		}
+	}
+
+	return nil
+}
+
+// validateFields will validate the sparse fields for the schemas type.
+func (s *Schema) validateFields(typ string, fields []string) error {
+	for _, field := range fields {
+		if !contains(s.Fields, field) {
+			return BadRequestParam("invalid sparse field", "fields["+typ+"]")
+		}
+	}
+
+	return nil
+}
+
+// includes returns whether the path or a path it is the parent of is allowed.
+func (s *Schema) includes(path string) bool {
+	for _, allowed := range s.Include {
+		if allowed == path || strings.HasPrefix(allowed, path+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateSchemas will validate the request using the schemas of the parser.
+func (p *Parser) validateSchemas(req *Request) error {
+	// check sparse fields
+	for _, typ := range sortedParams(req.Fields) {
+		if schema := p.Schemas[typ]; schema != nil {
+			err := schema.validateFields(typ, req.Fields[typ])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// get schema
+	schema := p.Schemas[req.ResourceType]
+	if schema == nil {
+		return nil
+	}
+
+	// check other parameters if the primary data is of the schemas type
+	switch req.Intent {
+	case ListResources, FindResource, CreateResource, UpdateResource, DeleteResource:
+		return schema.validate(req)
+	}
+
+	// check sorting and include using the schema of the related type
+	if req.Intent == GetRelatedResources {
+		if related := p.Schemas[schema.Relationships[req.RelatedResource]]; related != nil {
+			return related.validateSortingAndInclude(req)
+		}
+	}
+
+	return nil
+}
+
+// knownParameter returns whether the query parameter is handled by the parser
+// or is an implementation specific parameter. Unknown parameters that only
+// consist of lowercase letters are reserved by the specification.
+func knownParameter(key string) bool {
+	// get family name
+	if i := strings.Index(key, "["); i >= 0 {
+		key = key[:i]
+	}
+
+	// check known parameters
+	switch key {
+	case "include", "sort", "fields", "filter", "page", "pagination", "search":
+		return true
+	}
+
+	// check for implementation specific characters
+	for _, c := range key {
+		if c < 'a' || c > 'z' {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedParams will return the sorted keys of the passed parameters.
+func sortedParams(params map[string][]string) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, str string) bool {
+	for _, item := range list {
+		if item == str {
+			return true
+		}
+	}
+
+	return false
+}
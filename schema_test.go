@@ -0,0 +1,144 @@
+package jsonapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRequestSchema(t *testing.T) {
+	parser := &Parser{
+		FilterConditions: true,
+		Schemas: map[string]*Schema{
+			"posts": {
+				Sorting:         []string{"title", "created"},
+				Include:         []string{"author.company", "comments"},
+				MaxIncludeDepth: 2,
+				Fields:          []string{"title", "author"},
+				Filters: map[string]FilterType{
+					"title":     StringFilter,
+					"age":       NumberFilter,
+					"published": BooleanFilter,
+					"created":   TimeFilter,
+				},
+				Relationships: map[string]string{
+					"author": "users",
+				},
+			},
+			"users": {
+				Sorting: []string{"name"},
+				Fields:  []string{"name"},
+			},
+		},
+	}
+
+	req, err := parser.ParseRequest(newTestRequest("GET", "posts?sort=-created,title&include=author,author.company&fields[posts]=title&fields[users]=name&filter[age][gte]=1.5&filter[published]=true&filter[title][like]=f*&filter[created][lt]=2020-01-01T00:00:00Z&camelCase=1"))
+	assert.NoError(t, err)
+	assert.NotNil(t, req)
+
+	list := []struct {
+		u string
+		p string
+		e string
+	}{
+		{
+			u: "posts?sort=-foo",
+			p: "sort",
+			e: "invalid sort field",
+		},
+		{
+			u: "posts?include=author.foo",
+			p: "include",
+			e: "invalid include path",
+		},
+		{
+			u: "posts?include=author.company.owner",
+			p: "include",
+			e: "include path too deep",
+		},
+		{
+			u: "posts?fields[posts]=body",
+			p: "fields[posts]",
+			e: "invalid sparse field",
+		},
+		{
+			u: "comments/1/author?fields[users]=email",
+			p: "fields[users]",
+			e: "invalid sparse field",
+		},
+		{
+			u: "posts?filter[body]=foo",
+			p: "filter[body]",
+			e: "invalid filter field",
+		},
+		{
+			u: "posts?filter[age][gt]=foo",
+			p: "filter[age][gt]",
+			e: "invalid filter value",
+		},
+		{
+			u: "posts?filter[age]=1,foo",
+			p: "filter[age][in]",
+			e: "invalid filter value",
+		},
+		{
+			u: "posts?filter[published][gt]=true",
+			p: "filter[published][gt]",
+			e: "unsupported filter operator",
+		},
+		{
+			u: "posts?filter[created][like]=2020*",
+			p: "filter[created][like]",
+			e: "unsupported filter operator",
+		},
+		{
+			u: "posts/1?foo=bar",
+			p: "foo",
+			e: "unsupported query parameter",
+		},
+	}
+
+	for _, item := range list {
+		req, err := parser.ParseRequest(newTestRequest("GET", item.u))
+		assert.Equal(t, BadRequestParam(item.e, item.p), err, item.u)
+		assert.Nil(t, req, item.u)
+	}
+
+	// raw filters
+	parser.FilterConditions = false
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "posts?filter[age]=12"))
+	assert.NoError(t, err)
+	assert.NotNil(t, req)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "posts?filter[age]=foo"))
+	assert.Equal(t, BadRequestParam("invalid filter value", "filter[age]"), err)
+	assert.Nil(t, req)
+
+	// related resources
+	req, err = parser.ParseRequest(newTestRequest("GET", "posts/1/author?sort=-name"))
+	assert.NoError(t, err)
+	assert.NotNil(t, req)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "posts/1/author?sort=title"))
+	assert.Equal(t, BadRequestParam("invalid sort field", "sort"), err)
+	assert.Nil(t, req)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "posts/1/author?include=comments"))
+	assert.Equal(t, BadRequestParam("invalid include path", "include"), err)
+	assert.Nil(t, req)
+
+	// unknown types
+	req, err = parser.ParseRequest(newTestRequest("GET", "comments?sort=baz"))
+	assert.NoError(t, err)
+	assert.NotNil(t, req)
+
+	req, err = parser.ParseRequest(newTestRequest("GET", "comments?foo=bar"))
+	assert.Equal(t, BadRequestParam("unsupported query parameter", "foo"), err)
+	assert.Nil(t, req)
+
+	// no schemas
+	req, err = (&Parser{}).ParseRequest(newTestRequest("GET", "comments?foo=bar"))
+	assert.Equal(t, BadRequestParam("unsupported query parameter", "foo"), err)
+	assert.Nil(t, req)
+}
@@ -1,28 +1,48 @@
 package jsonapi
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 )
 
+// An ActionHandler handles a custom action of a server. It receives the
+// parsed request and the raw request body. A returned document is written with
+// an "OK" status, no document results in a "No Content" response.
+//
+// Note: The handler is called within a store transaction. The passed store is
+// bound to that transaction.
+type ActionHandler func(req *Request, body []byte, store Store) (*Document, error)
+
 // ServerConfig is used to configure a server.
 type ServerConfig struct {
-	Prefix string
-	Types  []string
+	Prefix         string
+	Types          []string
+	AtomicEndpoint string
+
+	// The collection and resource action handlers keyed by action name and
+	// request method e.g. "publish" and "POST".
+	CollectionActions map[string]map[string]ActionHandler
+	ResourceActions   map[string]map[string]ActionHandler
+
+	// The jsonapi object that is added to responses that do not have one.
+	JSONAPI *JSONAPI
 }
 
-// Server implements a basic in-memory jsonapi resource server intended for
-// testing purposes.
+// Server implements a basic jsonapi resource server intended for testing
+// purposes. Resources are kept in a MemoryStore unless another store is set.
 type Server struct {
-	Config  ServerConfig
-	Parser  *Parser
-	Data    map[string]map[string]*Resource
-	Counter int
-	Mutex   sync.Mutex
+	Config ServerConfig
+	Parser *Parser
+	Store  Store
 }
 
 // NewServer will create and return a new server.
@@ -30,41 +50,56 @@ func NewServer(config ServerConfig) *Server {
 	// clean prefix
 	config.Prefix = "/" + strings.Trim(config.Prefix, "/")
 
+	// set default atomic endpoint
+	if config.AtomicEndpoint == "" {
+		config.AtomicEndpoint = "operations"
+	}
+
 	// prepare parser
 	parser := &Parser{
-		Prefix: config.Prefix,
+		Prefix:         config.Prefix,
+		AtomicEndpoint: config.AtomicEndpoint,
+		CursorCodec:    &CursorCodec{},
 	}
 
+	// add actions
+	parser.CollectionActions = actionMethods(config.CollectionActions)
+	parser.ResourceActions = actionMethods(config.ResourceActions)
+
 	return &Server{
 		Config: config,
-		Data:   map[string]map[string]*Resource{},
 		Parser: parser,
+		Store:  NewMemoryStore(),
 	}
 }
 
 // ServeHTTP implements the http.Handler interface.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// acquire mutex
-	s.Mutex.Lock()
-	defer s.Mutex.Unlock()
-
 	// parse request
 	req, err := s.Parser.ParseRequest(r)
+
+	// check if the URL addresses an action with other methods
+	if err != nil || (req.Intent != CollectionAction && req.Intent != ResourceAction) {
+		if allowed := s.actionMethods(r); len(allowed) > 0 {
+			_ = WriteError(w, s.notAllowed(w, allowed))
+			return
+		}
+	}
+
+	// check error
 	if err != nil {
 		_ = WriteError(w, err)
 		return
 	}
 
-	// check resource type if list is given
-	if len(s.Config.Types) > 0 {
-		var ok bool
-		for _, typ := range s.Config.Types {
-			if req.ResourceType == typ {
-				ok = true
-			}
-		}
-		if !ok {
-			_ = WriteError(w, BadRequest("unsupported resource type"))
+	// echo applied extensions and profiles
+	w.Header().Set("Content-Type", req.MediaType())
+
+	// check resource type, operations are checked individually
+	if req.Intent != AtomicOperations {
+		err = s.checkType(req.ResourceType)
+		if err != nil {
+			_ = WriteError(w, err)
 			return
 		}
 	}
@@ -72,7 +107,7 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// parse document
 	var doc *Document
 	if req.Intent.DocumentExpected() {
-		doc, err = ParseDocument(r.Body)
+		doc, err = s.Parser.ParseDocument(r.Body)
 		if err != nil {
 			_ = WriteError(w, err)
 			return
@@ -91,6 +126,18 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		err = s.updateResource(req, doc, w)
 	case DeleteResource:
 		err = s.deleteResource(req, w)
+	case GetRelatedResources:
+		err = s.getRelatedResources(req, w)
+	case GetRelationship:
+		err = s.getRelationship(req, w)
+	case SetRelationship, AppendToRelationship, RemoveFromRelationship:
+		err = s.modifyRelationship(req, doc, w)
+	case AtomicOperations:
+		err = s.performOperations(doc, w)
+	case CollectionAction:
+		err = s.performAction(req, s.Config.CollectionActions[req.CollectionAction], r, w)
+	case ResourceAction:
+		err = s.performAction(req, s.Config.ResourceActions[req.ResourceAction], r, w)
 	default:
 		err = BadRequest("unsupported request method")
 	}
@@ -99,68 +146,294 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) listResources(req *Request, w http.ResponseWriter) error {
-	// prepare list
-	var list []*Resource
+// Load will read a document from the reader and add its primary data and
+// included resources to the store. All resources must have an id. Generated
+// ids that are loaded advance the counter of the default store to avoid
+// collisions with later created resources.
+//
+// Note: The resources are loaded in a store transaction. If a resource is
+// invalid or already exists, none of the resources are loaded.
+func (s *Server) Load(r io.Reader) error {
+	// parse document
+	doc, err := ParseDocument(r)
+	if err != nil {
+		return err
+	}
+
+	// load document
+	return s.Store.Transaction(func(store Store) error {
+		return s.load(store, doc)
+	})
+}
+
+// LoadDir will read all JSON files in the directory and add their resources
+// to the store like Load does. The files must be named after the type of their
+// resources e.g. "posts.json". The files are loaded in a single transaction.
+func (s *Server) LoadDir(dir string) error {
+	// find files
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
 
-	// get resources from collection
-	coll := s.Data[req.ResourceType]
-	if coll != nil {
-		list = make([]*Resource, 0, len(coll))
-		for _, res := range coll {
-			list = append(list, res)
+	// parse documents
+	docs := make([]*Document, 0, len(paths))
+	for _, path := range paths {
+		doc, err := parseFile(path)
+		if err != nil {
+			return err
 		}
-	} else {
-		list = []*Resource{}
+
+		// check types
+		name := filepath.Base(path)
+		for _, res := range documentResources(doc) {
+			if res != nil && res.Type+".json" != name {
+				return BadRequest(fmt.Sprintf("mismatching resource type %s in file %s, no resources have been loaded", res.Type, name))
+			}
+		}
+
+		docs = append(docs, doc)
 	}
 
-	// sort list
-	sort.Slice(list, func(i, j int) bool {
-		return list[i].ID < list[j].ID
+	// load documents
+	return s.Store.Transaction(func(store Store) error {
+		for _, doc := range docs {
+			err := s.load(store, doc)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
 	})
+}
 
-	// get offset and limit
-	offset := int(req.PageOffset)
-	limit := int(req.PageLimit)
-	if offset == 0 && req.PageNumber > 0 {
-		offset = int(req.PageNumber * req.PageSize)
-		limit = offset + int(req.PageSize)
+// Dump will write all resources of the store as the primary data of a
+// document to the writer. The document can be read using Load.
+func (s *Server) Dump(w io.Writer) error {
+	// get resources
+	list, err := s.Store.Resources()
+	if err != nil {
+		return err
 	}
 
-	// check offset and limit
-	if offset > 0 && (offset >= len(list) || limit > len(list)) {
-		return BadRequest("invalid pagination parameters")
+	return dump(w, list)
+}
+
+// DumpDir will write all resources of the store to the directory using one
+// file per resource type named after the type e.g. "posts.json". The
+// directory can be read using LoadDir.
+func (s *Server) DumpDir(dir string) error {
+	// get resources
+	list, err := s.Store.Resources()
+	if err != nil {
+		return err
 	}
 
-	// apply pagination
-	if offset > 0 {
-		list = list[offset:limit]
+	// group resources, the list is sorted by type
+	for len(list) > 0 {
+		// get group
+		n := 1
+		for n < len(list) && list[n].Type == list[0].Type {
+			n++
+		}
+
+		// write file
+		err = dumpFile(filepath.Join(dir, list[0].Type+".json"), list[:n])
+		if err != nil {
+			return err
+		}
+
+		list = list[n:]
 	}
 
-	return WriteResources(w, http.StatusOK, list, &DocumentLinks{
-		Self: Link(req.Self()),
+	return nil
+}
+
+func (s *Server) load(store Store, doc *Document) error {
+	// add resources
+	for _, res := range documentResources(doc) {
+		// check id
+		if res == nil || res.ID == "" {
+			return BadRequest("missing resource id")
+		}
+
+		// check existence
+		_, err := store.Find(res.Type, res.ID)
+		if err == nil {
+			return BadRequest(fmt.Sprintf("conflicting resource %s/%s, no resources have been loaded", res.Type, res.ID))
+		}
+
+		// remove links, they are generated when served
+		res.Links = nil
+		for _, rel := range res.Relationships {
+			if rel != nil {
+				rel.Links = nil
+			}
+		}
+
+		// create resource
+		err = store.Create(res)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func documentResources(doc *Document) []*Resource {
+	// collect resources
+	var list []*Resource
+	if doc.Data != nil && doc.Data.One != nil {
+		list = append(list, doc.Data.One)
+	} else if doc.Data != nil {
+		list = append(list, doc.Data.Many...)
+	}
+	list = append(list, doc.Included...)
+
+	return list
+}
+
+func parseFile(path string) (*Document, error) {
+	// open file
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseDocument(file)
+}
+
+func dumpFile(path string, list []*Resource) error {
+	// create file
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	// write document
+	err = dump(file, list)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func dump(w io.Writer, list []*Resource) error {
+	// prepare encoder
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(&Document{
+		Data: &HybridResource{Many: list},
 	})
 }
 
+func (s *Server) listResources(req *Request, w http.ResponseWriter) error {
+	// list resources
+	list, err := s.Store.List(req)
+	if err != nil {
+		return err
+	}
+
+	// paginate list
+	list, links, meta, err := s.paginate(req, list)
+	if err != nil {
+		return err
+	}
+
+	// link resources
+	for i, res := range list {
+		list[i] = s.linkResource(res)
+	}
+
+	// prepare builder
+	builder := &DocumentBuilder{
+		Links: links,
+		Meta:  meta,
+	}
+
+	// add resources
+	builder.Many(list...)
+	s.include(builder, list, req.IncludeTree())
+
+	// build document
+	doc, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return s.write(w, req, http.StatusOK, doc)
+}
+
 func (s *Server) findResources(req *Request, w http.ResponseWriter) error {
-	// get collection
-	coll := s.Data[req.ResourceType]
-	if coll == nil {
-		return NotFound("unknown resource")
+	// find resource
+	res, err := s.Store.Find(req.ResourceType, req.ResourceID)
+	if err != nil {
+		return err
+	}
+
+	// link resource
+	res = s.linkResource(res)
+
+	// prepare builder
+	builder := &DocumentBuilder{
+		Links: &DocumentLinks{
+			Self: &Link{Href: req.Self()},
+		},
+	}
+
+	// add resource
+	builder.One(res)
+	s.include(builder, []*Resource{res}, req.IncludeTree())
+
+	// build document
+	doc, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return s.write(w, req, http.StatusOK, doc)
+}
+
+func (s *Server) createResource(req *Request, doc *Document, w http.ResponseWriter) error {
+	// check document
+	if doc.Data == nil || doc.Data.One == nil {
+		return BadRequest("missing resource")
 	}
 
 	// get resource
-	res := coll[req.ResourceID]
-	if res == nil {
-		return NotFound("unknown resource")
+	res := doc.Data.One
+
+	// create resource, self references require a second write
+	var err error
+	if res.LID != "" && referencesLocalID(res, res.Type, res.LID) {
+		err = s.Store.Transaction(func(store Store) error {
+			return s.create(store, req.ResourceType, res, LocalIDs{})
+		})
+	} else {
+		err = s.create(s.Store, req.ResourceType, res, LocalIDs{})
+	}
+	if err != nil {
+		return err
 	}
 
-	return WriteResource(w, http.StatusOK, res, &DocumentLinks{
-		Self: Link(req.Self()),
+	// set id
+	req.ResourceID = res.ID
+
+	return s.write(w, req, http.StatusCreated, &Document{
+		Data: &HybridResource{One: s.linkResource(res)},
+		Links: &DocumentLinks{
+			Self: &Link{Href: req.Self()},
+		},
 	})
 }
 
-func (s *Server) createResource(req *Request, doc *Document, w http.ResponseWriter) error {
+func (s *Server) updateResource(req *Request, doc *Document, w http.ResponseWriter) error {
 	// check document
 	if doc.Data == nil || doc.Data.One == nil {
 		return BadRequest("missing resource")
@@ -169,108 +442,928 @@ func (s *Server) createResource(req *Request, doc *Document, w http.ResponseWrit
 	// get resource
 	res := doc.Data.One
 
-	// check type
-	if res.Type != req.ResourceType {
-		return BadRequest("resource type mismatch")
+	// update resource
+	err := s.update(s.Store, req.ResourceType, req.ResourceID, res, LocalIDs{})
+	if err != nil {
+		return err
 	}
 
-	// check id
-	if res.ID == "" {
-		s.Counter++
-		res.ID = "s-" + strconv.Itoa(s.Counter)
+	return s.write(w, req, http.StatusOK, &Document{
+		Data: &HybridResource{One: s.linkResource(res)},
+		Links: &DocumentLinks{
+			Self: &Link{Href: req.Self()},
+		},
+	})
+}
+
+func (s *Server) deleteResource(req *Request, w http.ResponseWriter) error {
+	// delete resource
+	err := s.Store.Delete(req.ResourceType, req.ResourceID)
+	if err != nil {
+		return err
 	}
 
-	// link relationships
-	s.linkRelationships(res)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) paginate(req *Request, list []*Resource) ([]*Resource, *DocumentLinks, Map, error) {
+	// get total
+	total := int64(len(list))
 
-	// get collection
-	coll := s.Data[req.ResourceType]
-	if coll == nil {
-		coll = map[string]*Resource{}
-		s.Data[req.ResourceType] = coll
+	// handle cursor pagination
+	if req.Pagination == "cursor" || req.CursorBefore != nil || req.CursorAfter != nil {
+		return s.paginateCursor(req, list)
 	}
 
-	// check existence
-	if coll[res.ID] != nil {
-		return BadRequest("conflicting resource")
+	// get range
+	start, end := int64(0), total
+	if req.PageLimit > 0 {
+		start = req.PageOffset
+		end = start + req.PageLimit
+	} else if req.PageSize > 0 {
+		number := req.PageNumber
+		if number < 1 {
+			number = 1
+		}
+		start = (number - 1) * req.PageSize
+		end = start + req.PageSize
+	} else {
+		return list, req.PaginationLinks(total), nil, nil
 	}
 
-	// store resource
-	coll[res.ID] = res
+	// clamp range
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
 
-	// set id
-	req.ResourceID = res.ID
+	return list[start:end], req.PaginationLinks(total), PaginationMeta(total, false), nil
+}
+
+func (s *Server) paginateCursor(req *Request, list []*Resource) ([]*Resource, *DocumentLinks, Map, error) {
+	// get total
+	total := int64(len(list))
+
+	// get range
+	start, end := 0, len(list)
+	if req.CursorAfter != nil {
+		for start < end && s.compareCursor(req, list[start], req.CursorAfter) <= 0 {
+			start++
+		}
+	}
+	if req.CursorBefore != nil {
+		for end > start && s.compareCursor(req, list[end-1], req.CursorBefore) >= 0 {
+			end--
+		}
+	}
+
+	// apply page size, backwards if only paginating before a cursor
+	if size := int(req.PageSize); size > 0 && end-start > size {
+		if req.CursorBefore != nil && req.CursorAfter == nil {
+			start = end - size
+		} else {
+			end = start + size
+		}
+	}
+
+	// get cursors
+	var before, after string
+	if start > 0 && start < end {
+		before = s.encodeCursor(req, list[start])
+	}
+	if end < len(list) && start < end {
+		after = s.encodeCursor(req, list[end-1])
+	}
+
+	return list[start:end], req.PaginationLinks(total, before, after), PaginationMeta(total, false), nil
+}
+
+func (s *Server) encodeCursor(req *Request, res *Resource) string {
+	// encode cursor
+	token, _ := s.Parser.CursorCodec.Encode(req.Cursor(res))
+
+	return token
+}
+
+func (s *Server) compareCursor(req *Request, res *Resource, cursor *Cursor) int {
+	// compare values
+	fields := req.SortFields()
+	for i, field := range fields {
+		// check value
+		if i >= len(cursor.Values) {
+			return 0
+		}
+
+		// compare value
+		r := compareValues(sortValue(res, field.Name), cursor.Values[i])
+		if field.Descending {
+			r = -r
+		}
+		if r != 0 {
+			return r
+		}
+	}
+
+	// compare id
+	if len(cursor.Values) > len(fields) {
+		return strings.Compare(res.ID, fmt.Sprint(cursor.Values[len(fields)]))
+	}
+
+	return 0
+}
 
-	return WriteResource(w, http.StatusCreated, res, &DocumentLinks{
-		Self: Link(req.Self()),
+func (s *Server) include(builder *DocumentBuilder, list []*Resource, node *IncludeNode) {
+	for _, child := range node.Children {
+		// collect related resources
+		var related []*Resource
+		for _, res := range list {
+			// get relationship
+			rel := res.Relationships[child.Name]
+			if rel == nil || rel.Data == nil {
+				continue
+			}
+
+			// get identifiers
+			ids := rel.Data.Many
+			if rel.Data.One != nil {
+				ids = []*Resource{rel.Data.One}
+			}
+
+			// find resources, missing resources are skipped
+			for _, id := range ids {
+				res, err := s.Store.Find(id.Type, id.ID)
+				if err == nil {
+					related = append(related, s.linkResource(res))
+				}
+			}
+		}
+
+		// include resources
+		builder.Include(related...)
+		s.include(builder, related, child)
+	}
+}
+
+func (s *Server) getRelatedResources(req *Request, w http.ResponseWriter) error {
+	// get relationship
+	_, rel, err := s.relationship(req.ResourceType, req.ResourceID, req.RelatedResource)
+	if err != nil {
+		return err
+	}
+
+	// handle to-many relationships
+	if rel.Data != nil && rel.Data.Many != nil {
+		// find resources, missing resources are skipped
+		list := make([]*Resource, 0, len(rel.Data.Many))
+		for _, id := range rel.Data.Many {
+			res, err := s.Store.Find(id.Type, id.ID)
+			if err == nil && matchesRequest(res, req) {
+				list = append(list, res)
+			}
+		}
+
+		// sort list
+		sortResources(list, req.SortFields())
+
+		// paginate list
+		list, links, meta, err := s.paginate(req, list)
+		if err != nil {
+			return err
+		}
+
+		// link resources
+		for i, res := range list {
+			list[i] = s.linkResource(res)
+		}
+
+		// prepare builder
+		builder := &DocumentBuilder{
+			Links: links,
+			Meta:  meta,
+		}
+
+		// add resources
+		builder.Many(list...)
+		s.include(builder, list, req.IncludeTree())
+
+		// build document
+		doc, err := builder.Build()
+		if err != nil {
+			return err
+		}
+
+		return s.write(w, req, http.StatusOK, doc)
+	}
+
+	// prepare builder
+	builder := &DocumentBuilder{
+		Links: &DocumentLinks{
+			Self: &Link{Href: req.Self()},
+		},
+	}
+
+	// find related resource, a missing resource is treated as empty
+	var res *Resource
+	if rel.Data != nil && rel.Data.One != nil {
+		res, err = s.Store.Find(rel.Data.One.Type, rel.Data.One.ID)
+		if err != nil {
+			res = nil
+		}
+	}
+
+	// add resource
+	if res != nil {
+		res = s.linkResource(res)
+		builder.One(res)
+		s.include(builder, []*Resource{res}, req.IncludeTree())
+	} else {
+		builder.One(nil)
+	}
+
+	// build document
+	doc, err := builder.Build()
+	if err != nil {
+		return err
+	}
+
+	return s.write(w, req, http.StatusOK, doc)
+}
+
+func (s *Server) getRelationship(req *Request, w http.ResponseWriter) error {
+	// get relationship
+	_, rel, err := s.relationship(req.ResourceType, req.ResourceID, req.Relationship)
+	if err != nil {
+		return err
+	}
+
+	return s.write(w, nil, http.StatusOK, s.linkageDocument(req, rel))
+}
+
+func (s *Server) modifyRelationship(req *Request, doc *Document, w http.ResponseWriter) error {
+	// modify relationship
+	var rel *Relationship
+	err := s.Store.Transaction(func(store Store) error {
+		var err error
+		rel, err = s.updateRelationship(store, req, doc.Data)
+		return err
 	})
+	if err != nil {
+		return err
+	}
+
+	return s.write(w, nil, http.StatusOK, s.linkageDocument(req, rel))
 }
 
-func (s *Server) updateResource(req *Request, doc *Document, w http.ResponseWriter) error {
+func (s *Server) updateRelationship(store Store, req *Request, linkage *HybridResource) (*Relationship, error) {
+	// find resource
+	res, err := store.Find(req.ResourceType, req.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// get relationship
+	rel := res.Relationships[req.Relationship]
+	toMany := rel != nil && rel.Data != nil && rel.Data.Many != nil
+
+	// get identifiers
+	var ids []*Resource
+	if linkage != nil && linkage.One != nil {
+		ids = []*Resource{linkage.One}
+	} else if linkage != nil {
+		ids = linkage.Many
+	}
+
+	// check identifiers
+	for _, id := range ids {
+		if id == nil || id.ID == "" {
+			return nil, BadRequest("invalid resource identifier")
+		}
+	}
+
+	// check related resources
+	if req.Intent != RemoveFromRelationship {
+		for _, id := range ids {
+			_, err = store.Find(id.Type, id.ID)
+			if err != nil {
+				return nil, NotFound("unknown related resource")
+			}
+		}
+	}
+
+	// prepare data
+	var data *HybridResource
+
+	// handle intent
+	switch req.Intent {
+	case SetRelationship:
+		// check linkage
+		if rel != nil && toMany && (linkage == nil || linkage.Many == nil) {
+			return nil, BadRequest("expected to-many linkage")
+		} else if rel != nil && !toMany && linkage != nil && linkage.Many != nil {
+			return nil, BadRequest("expected to-one linkage")
+		}
+
+		// replace linkage
+		data = &HybridResource{}
+		if linkage != nil && linkage.Many != nil {
+			data.Many = identifiers(ids)
+		} else if len(ids) > 0 {
+			data.One = identifiers(ids)[0]
+		}
+	case AppendToRelationship, RemoveFromRelationship:
+		// check relationship
+		if rel == nil && req.Intent == RemoveFromRelationship {
+			return nil, NotFound("unknown relationship")
+		} else if rel != nil && !toMany {
+			return nil, BadRequest("expected to-many relationship")
+		}
+
+		// check linkage
+		if linkage == nil || linkage.Many == nil {
+			return nil, BadRequest("expected to-many linkage")
+		}
+
+		// get current linkage
+		var current []*Resource
+		if rel != nil {
+			current = rel.Data.Many
+		}
+
+		// modify linkage
+		data = &HybridResource{Many: []*Resource{}}
+		for _, id := range current {
+			if req.Intent == AppendToRelationship || !containsIdentifier(ids, id) {
+				data.Many = append(data.Many, id)
+			}
+		}
+		if req.Intent == AppendToRelationship {
+			for _, id := range identifiers(ids) {
+				if !containsIdentifier(data.Many, id) {
+					data.Many = append(data.Many, id)
+				}
+			}
+		}
+	}
+
+	// prepare relationship
+	newRel := &Relationship{
+		Data: data,
+	}
+	if rel != nil {
+		newRel.Meta = rel.Meta
+	}
+
+	// store relationship
+	err = store.SetRelationship(req.ResourceType, req.ResourceID, req.Relationship, newRel)
+	if err != nil {
+		return nil, err
+	}
+
+	return newRel, nil
+}
+
+func (s *Server) performAction(req *Request, handlers map[string]ActionHandler, r *http.Request, w http.ResponseWriter) error {
+	// get handler
+	handler := handlers[r.Method]
+	if handler == nil {
+		return s.notAllowed(w, handlerMethods(handlers))
+	}
+
+	// read body
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return BadRequest("failed to read body")
+	}
+
+	// call handler
+	var doc *Document
+	err = s.Store.Transaction(func(store Store) error {
+		doc, err = handler(req, body, store)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
 	// check document
-	if doc.Data == nil || doc.Data.One == nil {
-		return BadRequest("missing resource")
+	if doc == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
 	}
 
-	// get resource
-	res := doc.Data.One
+	return s.write(w, nil, http.StatusOK, doc)
+}
+
+func (s *Server) performOperations(doc *Document, w http.ResponseWriter) error {
+	// check operations
+	if len(doc.Operations) == 0 {
+		return BadRequestPointer("missing operations", "/atomic:operations")
+	}
+
+	// prepare local ids
+	lids := LocalIDs{}
+
+	// perform operations, changes are rolled back on error
+	results := make([]*Result, 0, len(doc.Operations))
+	var hasData bool
+	err := s.Store.Transaction(func(store Store) error {
+		for i, op := range doc.Operations {
+			result, err := s.performOperation(store, op, lids)
+			if err != nil {
+				return operationError(err, i)
+			}
+
+			// add result
+			results = append(results, result)
+			if result.Data != nil {
+				hasData = true
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// check data
+	if !hasData {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+
+	return s.write(w, nil, http.StatusOK, &Document{
+		Results: results,
+	})
+}
+
+func (s *Server) performOperation(store Store, op *Operation, lids LocalIDs) (*Result, error) {
+	// get target
+	req, err := s.operationTarget(op, lids)
+	if err != nil {
+		return nil, err
+	}
 
 	// check type
-	if res.Type != req.ResourceType {
+	err = s.checkType(req.ResourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	// handle intent
+	switch req.Intent {
+	case CreateResource:
+		// check data
+		if op.Data == nil || op.Data.One == nil {
+			return nil, BadRequest("missing resource")
+		}
+
+		// create resource
+		err = s.create(store, req.ResourceType, op.Data.One, lids)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Result{Data: &HybridResource{One: s.linkResource(op.Data.One)}}, nil
+	case UpdateResource:
+		// check data
+		if op.Data == nil || op.Data.One == nil {
+			return nil, BadRequest("missing resource")
+		}
+
+		// resolve local id
+		if op.Data.One.ID == "" && op.Data.One.LID != "" {
+			op.Data.One.ID = req.ResourceID
+			op.Data.One.LID = ""
+		}
+
+		// update resource
+		err = s.update(store, req.ResourceType, req.ResourceID, op.Data.One, lids)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Result{Data: &HybridResource{One: s.linkResource(op.Data.One)}}, nil
+	case DeleteResource:
+		// delete resource
+		err = store.Delete(req.ResourceType, req.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Result{}, nil
+	case SetRelationship, AppendToRelationship, RemoveFromRelationship:
+		// resolve local ids
+		if op.Data != nil {
+			for _, id := range append([]*Resource{op.Data.One}, op.Data.Many...) {
+				err = lids.resolve(id)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// update relationship
+		_, err = s.updateRelationship(store, req, op.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Result{}, nil
+	}
+
+	return nil, BadRequest("unsupported operation")
+}
+
+func (s *Server) operationTarget(op *Operation, lids LocalIDs) (*Request, error) {
+	// check target
+	if op.Ref != nil && op.Href != "" {
+		return nil, BadRequest("operation must not contain both ref and href")
+	}
+
+	// get method
+	var method string
+	switch op.Op {
+	case AddOperation:
+		method = "POST"
+	case UpdateOperation:
+		method = "PATCH"
+	case RemoveOperation:
+		method = "DELETE"
+	default:
+		return nil, BadRequest("invalid operation code")
+	}
+
+	// parse href
+	if op.Href != "" {
+		r, err := http.NewRequest(method, op.Href, nil)
+		if err != nil {
+			return nil, BadRequest("invalid operation href")
+		}
+		r.Header.Set("Content-Type", MediaType)
+		return s.Parser.ParseRequest(r)
+	}
+
+	// prepare request
+	req := &Request{}
+
+	// get target from reference or data
+	var lid string
+	if op.Ref != nil {
+		req.ResourceType = op.Ref.Type
+		req.ResourceID = op.Ref.ID
+		req.Relationship = op.Ref.Relationship
+		lid = op.Ref.LID
+	} else if op.Data != nil && op.Data.One != nil {
+		req.ResourceType = op.Data.One.Type
+		if op.Op != AddOperation {
+			req.ResourceID = op.Data.One.ID
+			lid = op.Data.One.LID
+		}
+	} else {
+		return nil, BadRequest("missing operation target")
+	}
+
+	// resolve local id
+	if req.ResourceID == "" && lid != "" {
+		id, ok := lids.Lookup(req.ResourceType, lid)
+		if !ok {
+			return nil, BadRequest("unknown local id")
+		}
+		req.ResourceID = id
+	}
+
+	// calculate intent
+	switch method {
+	case "POST":
+		if req.Relationship != "" {
+			req.Intent = AppendToRelationship
+		} else if req.ResourceID == "" {
+			req.Intent = CreateResource
+		}
+	case "PATCH":
+		if req.Relationship != "" {
+			req.Intent = SetRelationship
+		} else if req.ResourceID != "" {
+			req.Intent = UpdateResource
+		}
+	case "DELETE":
+		if req.Relationship != "" {
+			req.Intent = RemoveFromRelationship
+		} else if req.ResourceID != "" {
+			req.Intent = DeleteResource
+		}
+	}
+
+	// check intent
+	if req.Intent == 0 || (req.Relationship != "" && req.ResourceID == "") {
+		return nil, BadRequest("invalid operation target")
+	}
+
+	return req, nil
+}
+
+func (s *Server) checkType(typ string) error {
+	// check resource type if list is given
+	if len(s.Config.Types) > 0 {
+		for _, t := range s.Config.Types {
+			if typ == t {
+				return nil
+			}
+		}
+
+		return BadRequest("unsupported resource type")
+	}
+
+	return nil
+}
+
+func (s *Server) create(store Store, typ string, res *Resource, lids LocalIDs) error {
+	// check type
+	if res.Type != typ {
+		return BadRequest("resource type mismatch")
+	}
+
+	// get local id
+	lid := res.LID
+	res.LID = ""
+
+	// check self references
+	self := lid != "" && referencesLocalID(res, res.Type, lid)
+
+	// resolve local ids before storing the resource if possible
+	if !self {
+		err := lids.Resolve(res)
+		if err != nil {
+			return err
+		}
+	}
+
+	// store resource to assign an id
+	err := store.Create(res)
+	if err != nil {
+		return err
+	}
+
+	// record local id
+	lids.Add(&Resource{Type: res.Type, ID: res.ID, LID: lid})
+
+	// resolve self references and store resolved relationships
+	if self {
+		err = lids.Resolve(res)
+		if err != nil {
+			return err
+		}
+		err = store.Update(res)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) update(store Store, typ, id string, res *Resource, lids LocalIDs) error {
+	// check type
+	if res.Type != typ {
 		return BadRequest("resource type mismatch")
 	}
 
 	// check id
-	if res.ID != req.ResourceID {
+	if res.ID != id {
 		return BadRequest("resource id mismatch")
 	}
 
-	// link relationships
-	s.linkRelationships(res)
+	// resolve local ids
+	err := lids.Resolve(res)
+	if err != nil {
+		return err
+	}
 
-	// get collection
-	coll := s.Data[req.ResourceType]
-	if coll == nil {
-		return NotFound("unknown resource")
+	// store resource
+	err = store.Update(res)
+	if err != nil {
+		return err
 	}
 
-	// get resource
-	if coll[req.ResourceID] == nil {
-		return NotFound("unknown resource")
+	// get merged resource
+	stored, err := store.Find(typ, id)
+	if err != nil {
+		return err
 	}
+	*res = *stored
 
-	// update resource
-	coll[req.ResourceID] = res
+	return nil
+}
 
-	return WriteResource(w, http.StatusOK, res, &DocumentLinks{
-		Self: Link(req.Self()),
-	})
+func (s *Server) relationship(typ, id, name string) (*Resource, *Relationship, error) {
+	// find resource
+	res, err := s.Store.Find(typ, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// get relationship
+	rel := res.Relationships[name]
+	if rel == nil {
+		return nil, nil, NotFound("unknown relationship")
+	}
+
+	return res, rel, nil
 }
 
-func (s *Server) deleteResource(req *Request, w http.ResponseWriter) error {
-	// get collection
-	coll := s.Data[req.ResourceType]
-	if coll == nil {
-		return NotFound("unknown resource")
+func (s *Server) linkageDocument(req *Request, rel *Relationship) *Document {
+	// prepare requests
+	self := Request{
+		Prefix:       req.Prefix,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceID,
+		Relationship: req.Relationship,
+	}
+	related := Request{
+		Prefix:          req.Prefix,
+		ResourceType:    req.ResourceType,
+		ResourceID:      req.ResourceID,
+		RelatedResource: req.Relationship,
 	}
 
-	// check resource
-	if coll[req.ResourceID] == nil {
-		return NotFound("unknown resource")
+	// ensure data
+	data := rel.Data
+	if data == nil {
+		data = &HybridResource{}
 	}
 
-	// delete resource
-	delete(coll, req.ResourceID)
+	return &Document{
+		Data: data,
+		Links: &DocumentLinks{
+			Self:    &Link{Href: self.Path()},
+			Related: &Link{Href: related.Path()},
+		},
+		Meta: rel.Meta,
+	}
+}
 
-	w.WriteHeader(http.StatusNoContent)
-	return nil
+func operationError(err error, index int) error {
+	// set pointer to operation if missing
+	if anError, ok := err.(*Error); ok && anError.Source == nil {
+		anError.Source = &ErrorSource{
+			Pointer: "/atomic:operations/" + strconv.Itoa(index),
+		}
+	}
+
+	return err
+}
+
+func compareValues(a, b interface{}) int {
+	// handle missing values
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	// compare numbers
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
 }
 
-func (s *Server) linkRelationships(res *Resource) {
-	for name, doc := range res.Relationships {
-		doc.Links = &DocumentLinks{
-			Self:    Link(fmt.Sprintf("%s/%s/%s/relationships/%s", s.Config.Prefix, res.Type, res.ID, name)),
-			Related: Link(fmt.Sprintf("%s/%s/%s/%s", s.Config.Prefix, res.Type, res.ID, name)),
+func toFloat(value interface{}) (float64, bool) {
+	// handle json numbers
+	if num, ok := value.(json.Number); ok {
+		f, err := num.Float64()
+		return f, err == nil
+	}
+
+	// handle other numbers
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+
+	return 0, false
+}
+
+// actionMethods will return the registered methods of the action addressed by
+// the URL of the request, if any.
+func (s *Server) actionMethods(r *http.Request) []string {
+	// check methods
+	var list []string
+	for _, method := range []string{"GET", "POST", "PATCH", "DELETE"} {
+		// prepare request
+		probe, err := http.NewRequest(method, r.URL.Path, nil)
+		if err != nil {
+			continue
+		}
+
+		// parse request
+		req, err := s.Parser.ParseRequest(probe)
+		if err != nil || (req.Intent != CollectionAction && req.Intent != ResourceAction) {
+			continue
+		}
+
+		// check resource type
+		if s.checkType(req.ResourceType) == nil {
+			list = append(list, method)
 		}
 	}
+
+	return list
+}
+
+func (s *Server) notAllowed(w http.ResponseWriter, allowed []string) error {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	return ErrorFromStatus(http.StatusMethodNotAllowed, "method not allowed")
+}
+
+func actionMethods(actions map[string]map[string]ActionHandler) map[string][]string {
+	// check actions
+	if len(actions) == 0 {
+		return nil
+	}
+
+	// collect methods
+	methods := make(map[string][]string, len(actions))
+	for name, handlers := range actions {
+		methods[name] = handlerMethods(handlers)
+	}
+
+	return methods
+}
+
+func handlerMethods(handlers map[string]ActionHandler) []string {
+	// collect methods
+	methods := make([]string, 0, len(handlers))
+	for method := range handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	return methods
+}
+
+func identifiers(list []*Resource) []*Resource {
+	// copy identifiers
+	ids := make([]*Resource, 0, len(list))
+	for _, res := range list {
+		ids = append(ids, &Resource{Type: res.Type, ID: res.ID})
+	}
+
+	return ids
+}
+
+func containsIdentifier(list []*Resource, id *Resource) bool {
+	for _, res := range list {
+		if res.Type == id.Type && res.ID == id.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// write will apply the sparse fieldsets of the request if available and add
+// the configured jsonapi object before writing the document.
+func (s *Server) write(w http.ResponseWriter, req *Request, status int, doc *Document) error {
+	// apply sparse fieldsets
+	if req != nil {
+		doc.ApplyFields(req.Fields)
+	}
+
+	// add jsonapi object
+	if doc.JSONAPI == nil && s.Config.JSONAPI != nil {
+		cpy := *doc
+		cpy.JSONAPI = s.Config.JSONAPI
+		doc = &cpy
+	}
+
+	return WriteResponse(w, status, doc)
+}
+
+func (s *Server) linkResource(res *Resource) *Resource {
+	return (&Request{Prefix: strings.Trim(s.Config.Prefix, "/")}).LinkResource(res)
 }
@@ -1,8 +1,14 @@
 package jsonapi
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,7 +24,7 @@ func TestServerCRUD(t *testing.T) {
 				Many: []*Resource{},
 			},
 			Links: &DocumentLinks{
-				Self: "/foo",
+				Self: &Link{Href: "/foo"},
 			},
 		}, doc)
 
@@ -44,15 +50,16 @@ func TestServerCRUD(t *testing.T) {
 		assert.Equal(t, &Document{
 			Data: &HybridResource{
 				One: &Resource{
-					Type: "foo",
-					ID:   "bar",
+					Type:  "foo",
+					ID:    "bar",
+					Links: &ResourceLinks{Self: &Link{Href: "/foo/bar"}},
 					Attributes: Map{
 						"foo": "bar",
 					},
 				},
 			},
 			Links: &DocumentLinks{
-				Self: "/foo/bar",
+				Self: &Link{Href: "/foo/bar"},
 			},
 		}, doc)
 
@@ -63,8 +70,9 @@ func TestServerCRUD(t *testing.T) {
 			Data: &HybridResource{
 				Many: []*Resource{
 					{
-						Type: "foo",
-						ID:   "bar",
+						Type:  "foo",
+						ID:    "bar",
+						Links: &ResourceLinks{Self: &Link{Href: "/foo/bar"}},
 						Attributes: Map{
 							"foo": "bar",
 						},
@@ -72,7 +80,7 @@ func TestServerCRUD(t *testing.T) {
 				},
 			},
 			Links: &DocumentLinks{
-				Self: "/foo",
+				Self: &Link{Href: "/foo"},
 			},
 		}, doc)
 
@@ -82,18 +90,24 @@ func TestServerCRUD(t *testing.T) {
 		assert.Equal(t, &Document{
 			Data: &HybridResource{
 				One: &Resource{
-					Type: "foo",
-					ID:   "bar",
+					Type:  "foo",
+					ID:    "bar",
+					Links: &ResourceLinks{Self: &Link{Href: "/foo/bar"}},
 					Attributes: Map{
 						"foo": "bar",
 					},
 				},
 			},
 			Links: &DocumentLinks{
-				Self: "/foo/bar",
+				Self: &Link{Href: "/foo/bar"},
 			},
 		}, doc)
 
+		// stored resource is not linked
+		res, err := server.Store.Find("foo", "bar")
+		assert.NoError(t, err)
+		assert.Nil(t, res.Links)
+
 		// update
 		doc, err = client.Update(&Resource{
 			Type: "foo",
@@ -106,15 +120,16 @@ func TestServerCRUD(t *testing.T) {
 		assert.Equal(t, &Document{
 			Data: &HybridResource{
 				One: &Resource{
-					Type: "foo",
-					ID:   "bar",
+					Type:  "foo",
+					ID:    "bar",
+					Links: &ResourceLinks{Self: &Link{Href: "/foo/bar"}},
 					Attributes: Map{
 						"foo": "baz",
 					},
 				},
 			},
 			Links: &DocumentLinks{
-				Self: "/foo/bar",
+				Self: &Link{Href: "/foo/bar"},
 			},
 		}, doc)
 
@@ -125,8 +140,9 @@ func TestServerCRUD(t *testing.T) {
 			Data: &HybridResource{
 				Many: []*Resource{
 					{
-						Type: "foo",
-						ID:   "bar",
+						Type:  "foo",
+						ID:    "bar",
+						Links: &ResourceLinks{Self: &Link{Href: "/foo/bar"}},
 						Attributes: Map{
 							"foo": "baz",
 						},
@@ -134,7 +150,7 @@ func TestServerCRUD(t *testing.T) {
 				},
 			},
 			Links: &DocumentLinks{
-				Self: "/foo",
+				Self: &Link{Href: "/foo"},
 			},
 		}, doc)
 
@@ -144,15 +160,16 @@ func TestServerCRUD(t *testing.T) {
 		assert.Equal(t, &Document{
 			Data: &HybridResource{
 				One: &Resource{
-					Type: "foo",
-					ID:   "bar",
+					Type:  "foo",
+					ID:    "bar",
+					Links: &ResourceLinks{Self: &Link{Href: "/foo/bar"}},
 					Attributes: Map{
 						"foo": "baz",
 					},
 				},
 			},
 			Links: &DocumentLinks{
-				Self: "/foo/bar",
+				Self: &Link{Href: "/foo/bar"},
 			},
 		}, doc)
 
@@ -168,7 +185,7 @@ func TestServerCRUD(t *testing.T) {
 				Many: []*Resource{},
 			},
 			Links: &DocumentLinks{
-				Self: "/foo",
+				Self: &Link{Href: "/foo"},
 			},
 		}, doc)
 
@@ -184,15 +201,103 @@ func TestServerCRUD(t *testing.T) {
 	})
 }
 
+func TestServerPartialUpdate(t *testing.T) {
+	withServer(func(client *Client, server *Server) {
+		seed(server.Store, &Resource{
+			Type: "posts",
+			ID:   "1",
+			Attributes: Map{
+				"title": "foo",
+				"body":  "bar",
+			},
+			Relationships: map[string]*Relationship{
+				"author": {
+					Data: &HybridResource{One: &Resource{Type: "users", ID: "1"}},
+				},
+			},
+		})
+
+		// update
+		doc, err := client.Update(&Resource{
+			Type: "posts",
+			ID:   "1",
+			Attributes: Map{
+				"title": "baz",
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Map{
+			"title": "baz",
+			"body":  "bar",
+		}, doc.Data.One.Attributes)
+		assert.Equal(t, "1", doc.Data.One.Relationships["author"].Data.One.ID)
+
+		res := find(server.Store, "posts", "1")
+		assert.Equal(t, Map{
+			"title": "baz",
+			"body":  "bar",
+		}, res.Attributes)
+		assert.Equal(t, &HybridResource{
+			One: &Resource{Type: "users", ID: "1"},
+		}, res.Relationships["author"].Data)
+
+		// atomic update
+		doc, err = client.Atomic(Operation{
+			Op: UpdateOperation,
+			Data: &HybridResource{
+				One: &Resource{
+					Type: "posts",
+					ID:   "1",
+					Attributes: Map{
+						"body": "qux",
+					},
+					Relationships: map[string]*Relationship{
+						"editor": {
+							Data: &HybridResource{One: &Resource{Type: "users", ID: "2"}},
+						},
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Map{
+			"title": "baz",
+			"body":  "qux",
+		}, doc.Results[0].Data.One.Attributes)
+
+		res = find(server.Store, "posts", "1")
+		assert.Equal(t, Map{
+			"title": "baz",
+			"body":  "qux",
+		}, res.Attributes)
+		assert.Equal(t, &HybridResource{
+			One: &Resource{Type: "users", ID: "1"},
+		}, res.Relationships["author"].Data)
+		assert.Equal(t, &HybridResource{
+			One: &Resource{Type: "users", ID: "2"},
+		}, res.Relationships["editor"].Data)
+	})
+}
+
 func TestServerPagination(t *testing.T) {
 	withServer(func(client *Client, server *Server) {
-		server.Data["foo"] = map[string]*Resource{}
 		for i := 0; i < 5; i++ {
-			id := strconv.Itoa(i)
-			server.Data["foo"][id] = &Resource{
+			seed(server.Store, &Resource{
 				Type: "foo",
-				ID:   id,
-			}
+				ID:   strconv.Itoa(i),
+			})
+		}
+
+		link := func(query string) *Link {
+			return &Link{Href: escape(query)}
+		}
+
+		null := &Link{Href: NullLink}
+
+		meta := Map{
+			"page": map[string]interface{}{
+				"total": json.Number("5"),
+			},
 		}
 
 		// all
@@ -201,52 +306,1173 @@ func TestServerPagination(t *testing.T) {
 		assert.Equal(t, &Document{
 			Data: &HybridResource{
 				Many: []*Resource{
-					{Type: "foo", ID: "0"},
-					{Type: "foo", ID: "1"},
-					{Type: "foo", ID: "2"},
-					{Type: "foo", ID: "3"},
-					{Type: "foo", ID: "4"},
+					{Type: "foo", ID: "0", Links: &ResourceLinks{Self: &Link{Href: "/foo/0"}}},
+					{Type: "foo", ID: "1", Links: &ResourceLinks{Self: &Link{Href: "/foo/1"}}},
+					{Type: "foo", ID: "2", Links: &ResourceLinks{Self: &Link{Href: "/foo/2"}}},
+					{Type: "foo", ID: "3", Links: &ResourceLinks{Self: &Link{Href: "/foo/3"}}},
+					{Type: "foo", ID: "4", Links: &ResourceLinks{Self: &Link{Href: "/foo/4"}}},
 				},
 			},
 			Links: &DocumentLinks{
-				Self: "/foo",
+				Self: &Link{Href: "/foo"},
 			},
 		}, doc)
 
 		// number and size
 		doc, err = client.List("foo", Request{
-			PageNumber: 1,
+			PageNumber: 2,
 			PageSize:   2,
 		})
 		assert.NoError(t, err)
 		assert.Equal(t, &Document{
 			Data: &HybridResource{
 				Many: []*Resource{
-					{Type: "foo", ID: "2"},
-					{Type: "foo", ID: "3"},
+					{Type: "foo", ID: "2", Links: &ResourceLinks{Self: &Link{Href: "/foo/2"}}},
+					{Type: "foo", ID: "3", Links: &ResourceLinks{Self: &Link{Href: "/foo/3"}}},
 				},
 			},
 			Links: &DocumentLinks{
-				Self: Link(escape("/foo?page[number]=1&page[size]=2")),
+				Self:     link("/foo?page[number]=2&page[size]=2"),
+				First:    link("/foo?page[number]=1&page[size]=2"),
+				Previous: link("/foo?page[number]=1&page[size]=2"),
+				Next:     link("/foo?page[number]=3&page[size]=2"),
+				Last:     link("/foo?page[number]=3&page[size]=2"),
 			},
+			Meta: meta,
 		}, doc)
 
+		// last short page
+		doc, err = client.List("foo", Request{
+			PageNumber: 3,
+			PageSize:   2,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"4"}, resourceIDs(doc))
+		assert.Equal(t, null, doc.Links.Next)
+
+		// page out of range
+		doc, err = client.List("foo", Request{
+			PageNumber: 4,
+			PageSize:   2,
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, resourceIDs(doc))
+
+		// zero offset and limit
+		doc, err = client.List("foo", Request{
+			PageLimit: 2,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"0", "1"}, resourceIDs(doc))
+		assert.Equal(t, &DocumentLinks{
+			Self:     link("/foo?page[limit]=2"),
+			First:    link("/foo?page[limit]=2"),
+			Previous: null,
+			Next:     link("/foo?page[limit]=2&page[offset]=2"),
+			Last:     link("/foo?page[limit]=2&page[offset]=4"),
+		}, doc.Links)
+		assert.Equal(t, meta, doc.Meta)
+
 		// offset and limit
 		doc, err = client.List("foo", Request{
 			PageOffset: 3,
 			PageLimit:  5,
 		})
 		assert.NoError(t, err)
+		assert.Equal(t, []string{"3", "4"}, resourceIDs(doc))
+		assert.Equal(t, &DocumentLinks{
+			Self:     link("/foo?page[limit]=5&page[offset]=3"),
+			First:    link("/foo?page[limit]=5"),
+			Previous: link("/foo?page[limit]=5"),
+			Next:     null,
+			Last:     link("/foo?page[limit]=5&page[offset]=3"),
+		}, doc.Links)
+
+		// first cursor page
+		doc, err = client.List("foo", Request{
+			Pagination: "cursor",
+			PageSize:   2,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"0", "1"}, resourceIDs(doc))
+		assert.Equal(t, null, doc.Links.Previous)
+		assert.NotEqual(t, null, doc.Links.Next)
+		assert.Equal(t, meta, doc.Meta)
+
+		// follow next links
+		var ids []string
+		next := doc.Links.Next
+		for next.Href != NullLink {
+			r, err := http.NewRequest("GET", next.Href, nil)
+			assert.NoError(t, err)
+			req, err := server.Parser.ParseRequest(r)
+			assert.NoError(t, err)
+			doc, err = client.List("foo", *req)
+			assert.NoError(t, err)
+			ids = append(ids, resourceIDs(doc)...)
+			next = doc.Links.Next
+		}
+		assert.Equal(t, []string{"2", "3", "4"}, ids)
+
+		// previous page
+		r, err := http.NewRequest("GET", doc.Links.Previous.Href, nil)
+		assert.NoError(t, err)
+		req, err := server.Parser.ParseRequest(r)
+		assert.NoError(t, err)
+		doc, err = client.List("foo", *req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2", "3"}, resourceIDs(doc))
+
+		// sorted cursor pages
+		doc, err = client.List("foo", Request{
+			Pagination: "cursor",
+			PageSize:   3,
+			Sorting:    []string{"-id"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"4", "3", "2"}, resourceIDs(doc))
+
+		r, err = http.NewRequest("GET", doc.Links.Next.Href, nil)
+		assert.NoError(t, err)
+		req, err = server.Parser.ParseRequest(r)
+		assert.NoError(t, err)
+		doc, err = client.List("foo", *req)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "0"}, resourceIDs(doc))
+		assert.Equal(t, null, doc.Links.Next)
+
+		// invalid cursor
+		_, err = client.List("foo", Request{
+			PageAfter: "foo",
+		})
+		assert.Equal(t, CursorInvalidParameter("invalid cursor", "page[after]"), err)
+	})
+}
+
+func resourceIDs(doc *Document) []string {
+	var list []string
+	for _, res := range doc.Data.Many {
+		list = append(list, res.ID)
+	}
+	return list
+}
+
+func TestServerRelationships(t *testing.T) {
+	withServer(func(client *Client, server *Server) {
+		seed(server.Store, &Resource{
+			Type: "users",
+			ID:   "1",
+		}, &Resource{
+			Type: "users",
+			ID:   "2",
+		}, &Resource{
+			Type: "posts",
+			ID:   "1",
+			Relationships: map[string]*Relationship{
+				"author": {
+					Data: &HybridResource{One: &Resource{Type: "users", ID: "1"}},
+				},
+				"readers": {
+					Data: &HybridResource{Many: []*Resource{}},
+				},
+			},
+		})
+
+		user := func(id string) *Resource {
+			return &Resource{
+				Type:  "users",
+				ID:    id,
+				Links: &ResourceLinks{Self: &Link{Href: "/users/" + id}},
+			}
+		}
+
+		linkage := func(name string, data *HybridResource) *Document {
+			return &Document{
+				Data: data,
+				Links: &DocumentLinks{
+					Self:    &Link{Href: "/posts/1/relationships/" + name},
+					Related: &Link{Href: "/posts/1/" + name},
+				},
+			}
+		}
+
+		// get related to-one
+		doc, err := client.Do(Request{
+			Intent:          GetRelatedResources,
+			ResourceType:    "posts",
+			ResourceID:      "1",
+			RelatedResource: "author",
+		}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, &Document{
+			Data: &HybridResource{One: user("1")},
+			Links: &DocumentLinks{
+				Self: &Link{Href: "/posts/1/author"},
+			},
+		}, doc)
+
+		// get relationship
+		doc, err = client.Do(Request{
+			Intent:       GetRelationship,
+			ResourceType: "posts",
+			ResourceID:   "1",
+			Relationship: "author",
+		}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, linkage("author", &HybridResource{
+			One: &Resource{Type: "users", ID: "1"},
+		}), doc)
+
+		// set to-one relationship
+		doc, err = client.Do(Request{
+			Intent:       SetRelationship,
+			ResourceType: "posts",
+			ResourceID:   "1",
+			Relationship: "author",
+		}, &Document{
+			Data: &HybridResource{One: &Resource{Type: "users", ID: "2"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, linkage("author", &HybridResource{
+			One: &Resource{Type: "users", ID: "2"},
+		}), doc)
+
+		// set to-one relationship with to-many linkage
+		_, err = client.Do(Request{
+			Intent:       SetRelationship,
+			ResourceType: "posts",
+			ResourceID:   "1",
+			Relationship: "author",
+		}, &Document{
+			Data: &HybridResource{Many: []*Resource{}},
+		})
+		assert.Equal(t, BadRequest("expected to-one linkage"), err)
+
+		// set relationship with missing target
+		_, err = client.Do(Request{
+			Intent:       SetRelationship,
+			ResourceType: "posts",
+			ResourceID:   "1",
+			Relationship: "author",
+		}, &Document{
+			Data: &HybridResource{One: &Resource{Type: "users", ID: "3"}},
+		})
+		assert.Equal(t, NotFound("unknown related resource"), err)
+
+		// append to to-one relationship
+		_, err = client.Do(Request{
+			Intent:       AppendToRelationship,
+			ResourceType: "posts",
+			ResourceID:   "1",
+			Relationship: "author",
+		}, &Document{
+			Data: &HybridResource{Many: []*Resource{{Type: "users", ID: "1"}}},
+		})
+		assert.Equal(t, BadRequest("expected to-many relationship"), err)
+
+		// append to to-many relationship
+		doc, err = client.Do(Request{
+			Intent:       AppendToRelationship,
+			ResourceType: "posts",
+			ResourceID:   "1",
+			Relationship: "readers",
+		}, &Document{
+			Data: &HybridResource{Many: []*Resource{
+				{Type: "users", ID: "1"},
+				{Type: "users", ID: "2"},
+				{Type: "users", ID: "1"},
+			}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, linkage("readers", &HybridResource{Many: []*Resource{
+			{Type: "users", ID: "1"},
+			{Type: "users", ID: "2"},
+		}}), doc)
+
+		// get related to-many
+		doc, err = client.Do(Request{
+			Intent:          GetRelatedResources,
+			ResourceType:    "posts",
+			ResourceID:      "1",
+			RelatedResource: "readers",
+		}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, &Document{
+			Data: &HybridResource{Many: []*Resource{user("1"), user("2")}},
+			Links: &DocumentLinks{
+				Self: &Link{Href: "/posts/1/readers"},
+			},
+		}, doc)
+
+		// get related to-many with sorting, pagination and sparse fields
+		doc, err = client.Do(Request{
+			Intent:          GetRelatedResources,
+			ResourceType:    "posts",
+			ResourceID:      "1",
+			RelatedResource: "readers",
+			Sorting:         []string{"-id"},
+			PageNumber:      1,
+			PageSize:        1,
+			Fields:          map[string][]string{"users": {}},
+		}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []*Resource{user("2")}, doc.Data.Many)
+		assert.Equal(t, &Link{Href: "/posts/1/readers?" + escape("fields[users]=&page[number]=2&page[size]=1&sort=-id")}, doc.Links.Next)
+
+		// get related to-many with missing resources
+		err = server.Store.Create(&Resource{
+			Type: "posts",
+			ID:   "2",
+			Relationships: map[string]*Relationship{
+				"author": {
+					Data: &HybridResource{One: &Resource{Type: "users", ID: "3"}},
+				},
+				"readers": {
+					Data: &HybridResource{Many: []*Resource{
+						{Type: "users", ID: "3"},
+						{Type: "users", ID: "1"},
+					}},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		doc, err = client.Do(Request{
+			Intent:          GetRelatedResources,
+			ResourceType:    "posts",
+			ResourceID:      "2",
+			RelatedResource: "readers",
+		}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []*Resource{user("1")}, doc.Data.Many)
+		doc, err = client.Do(Request{
+			Intent:          GetRelatedResources,
+			ResourceType:    "posts",
+			ResourceID:      "2",
+			RelatedResource: "author",
+		}, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, doc.Data)
+
+		// remove from to-many relationship
+		doc, err = client.Do(Request{
+			Intent:       RemoveFromRelationship,
+			ResourceType: "posts",
+			ResourceID:   "1",
+			Relationship: "readers",
+		}, &Document{
+			Data: &HybridResource{Many: []*Resource{{Type: "users", ID: "1"}}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, linkage("readers", &HybridResource{Many: []*Resource{
+			{Type: "users", ID: "2"},
+		}}), doc)
+
+		// set to-many relationship
+		doc, err = client.Do(Request{
+			Intent:       SetRelationship,
+			ResourceType: "posts",
+			ResourceID:   "1",
+			Relationship: "readers",
+		}, &Document{
+			Data: &HybridResource{Many: []*Resource{}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, linkage("readers", &HybridResource{Many: []*Resource{}}), doc)
+
+		// clear to-one relationship
+		_, err = client.Do(Request{
+			Intent:       SetRelationship,
+			ResourceType: "posts",
+			ResourceID:   "1",
+			Relationship: "author",
+		}, &Document{})
+		assert.NoError(t, err)
+
+		// get empty related to-one
+		doc, err = client.Do(Request{
+			Intent:          GetRelatedResources,
+			ResourceType:    "posts",
+			ResourceID:      "1",
+			RelatedResource: "author",
+		}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, &Document{
+			Links: &DocumentLinks{
+				Self: &Link{Href: "/posts/1/author"},
+			},
+		}, doc)
+
+		// unknown relationship
+		_, err = client.Do(Request{
+			Intent:       GetRelationship,
+			ResourceType: "posts",
+			ResourceID:   "1",
+			Relationship: "foo",
+		}, nil)
+		assert.Equal(t, NotFound("unknown relationship"), err)
+
+		// unknown resource
+		_, err = client.Do(Request{
+			Intent:          GetRelatedResources,
+			ResourceType:    "posts",
+			ResourceID:      "3",
+			RelatedResource: "author",
+		}, nil)
+		assert.Equal(t, NotFound("unknown resource"), err)
+	})
+}
+
+func TestServerQueries(t *testing.T) {
+	withServer(func(client *Client, server *Server) {
+		seed(server.Store, &Resource{
+			Type:       "users",
+			ID:         "1",
+			Attributes: Map{"name": "Alice"},
+		}, &Resource{
+			Type:       "users",
+			ID:         "2",
+			Attributes: Map{"name": "Bob"},
+		})
+		for i, title := range []string{"Hello World", "Foo", "Bar", "Hello Foo"} {
+			seed(server.Store, &Resource{
+				Type: "posts",
+				ID:   strconv.Itoa(i + 1),
+				Attributes: Map{
+					"title":  title,
+					"rating": json.Number(strconv.Itoa(10 - i%2*10 + i)),
+					"status": []string{"draft", "published"}[i%2],
+				},
+				Relationships: map[string]*Relationship{
+					"author": {
+						Data: &HybridResource{One: &Resource{Type: "users", ID: strconv.Itoa(i%2 + 1)}},
+					},
+				},
+			})
+		}
+
+		// filter
+		doc, err := client.List("posts", Request{
+			Filters: map[string][]string{
+				"status": {"published"},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2", "4"}, resourceIDs(doc))
+
+		// filter with multiple values
+		doc, err = client.List("posts", Request{
+			Filters: map[string][]string{
+				"title": {"Foo", "Bar"},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2", "3"}, resourceIDs(doc))
+
+		// sort by number
+		doc, err = client.List("posts", Request{
+			Sorting: []string{"rating"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2", "4", "1", "3"}, resourceIDs(doc))
+
+		// sort descending with multiple fields
+		doc, err = client.List("posts", Request{
+			Sorting: []string{"-status", "-title"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"4", "2", "1", "3"}, resourceIDs(doc))
+
+		// search
+		doc, err = client.List("posts", Request{
+			Search: "hello",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "4"}, resourceIDs(doc))
+
+		// filter conditions
+		server.Parser.FilterConditions = true
+		doc, err = client.List("posts", Request{
+			Conditions: []Condition{
+				{Path: []string{"rating"}, Operator: GreaterThanOperator, Values: []string{"5"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "3"}, resourceIDs(doc))
+
+		doc, err = client.List("posts", Request{
+			Conditions: []Condition{
+				{Path: []string{"title"}, Operator: LikeOperator, Values: []string{"Hello*"}},
+				{Path: []string{"status"}, Operator: NotEqualOperator, Values: []string{"draft"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"4"}, resourceIDs(doc))
+
+		doc, err = client.List("posts", Request{
+			Conditions: []Condition{
+				{Path: []string{"id"}, Operator: InOperator, Values: []string{"2", "3"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"2", "3"}, resourceIDs(doc))
+		server.Parser.FilterConditions = false
+
+		// sparse fields and include
+		doc, err = client.List("posts", Request{
+			Filters: map[string][]string{
+				"id": {"1"},
+			},
+			Include: []string{"author"},
+			Fields: map[string][]string{
+				"posts": {"title"},
+			},
+		})
+		assert.NoError(t, err)
 		assert.Equal(t, &Document{
 			Data: &HybridResource{
 				Many: []*Resource{
-					{Type: "foo", ID: "3"},
-					{Type: "foo", ID: "4"},
+					{
+						Type:       "posts",
+						ID:         "1",
+						Attributes: Map{"title": "Hello World"},
+						Links:      &ResourceLinks{Self: &Link{Href: "/posts/1"}},
+					},
+				},
+			},
+			Included: []*Resource{
+				{
+					Type:       "users",
+					ID:         "1",
+					Attributes: Map{"name": "Alice"},
+					Links:      &ResourceLinks{Self: &Link{Href: "/users/1"}},
 				},
 			},
 			Links: &DocumentLinks{
-				Self: Link(escape("/foo?page[limit]=5&page[offset]=3")),
+				Self: &Link{Href: escape("/posts?fields[posts]=title&filter[id]=1&include=author")},
+			},
+		}, doc)
+
+		// find with include
+		doc, err = client.Find("posts", "2", Request{
+			Include: []string{"author"},
+			Fields: map[string][]string{
+				"posts": {},
+				"users": {"name"},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []*Resource{
+			{
+				Type:       "users",
+				ID:         "2",
+				Attributes: Map{"name": "Bob"},
+				Links:      &ResourceLinks{Self: &Link{Href: "/users/2"}},
+			},
+		}, doc.Included)
+	})
+}
+
+func TestServerAtomicOperations(t *testing.T) {
+	withServer(func(client *Client, server *Server) {
+		// add and update
+		doc, err := client.Atomic(Operation{
+			Op: AddOperation,
+			Data: &HybridResource{
+				One: &Resource{
+					Type: "foo",
+					ID:   "1",
+					Attributes: Map{
+						"foo": "bar",
+					},
+				},
+			},
+		}, Operation{
+			Op:   AddOperation,
+			Href: "/foo",
+			Data: &HybridResource{
+				One: &Resource{
+					Type: "foo",
+				},
+			},
+		}, Operation{
+			Op: UpdateOperation,
+			Ref: &Reference{
+				Type: "foo",
+				ID:   "1",
+			},
+			Data: &HybridResource{
+				One: &Resource{
+					Type: "foo",
+					ID:   "1",
+					Attributes: Map{
+						"foo": "baz",
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, &Document{
+			Results: []*Result{
+				{
+					Data: &HybridResource{
+						One: &Resource{
+							Type:  "foo",
+							ID:    "1",
+							Links: &ResourceLinks{Self: &Link{Href: "/foo/1"}},
+							Attributes: Map{
+								"foo": "bar",
+							},
+						},
+					},
+				},
+				{
+					Data: &HybridResource{
+						One: &Resource{
+							Type:  "foo",
+							ID:    "s-1",
+							Links: &ResourceLinks{Self: &Link{Href: "/foo/s-1"}},
+						},
+					},
+				},
+				{
+					Data: &HybridResource{
+						One: &Resource{
+							Type:  "foo",
+							ID:    "1",
+							Links: &ResourceLinks{Self: &Link{Href: "/foo/1"}},
+							Attributes: Map{
+								"foo": "baz",
+							},
+						},
+					},
+				},
 			},
 		}, doc)
+		assert.Equal(t, 2, count(server.Store, "foo"))
+
+		// remove
+		doc, err = client.Atomic(Operation{
+			Op: RemoveOperation,
+			Ref: &Reference{
+				Type: "foo",
+				ID:   "s-1",
+			},
+		})
+		assert.NoError(t, err)
+		assert.Nil(t, doc)
+		assert.Equal(t, 1, count(server.Store, "foo"))
+	})
+}
+
+func TestServerAtomicOperationsRollback(t *testing.T) {
+	withServer(func(client *Client, server *Server) {
+		seed(server.Store, &Resource{Type: "foo", ID: "1"})
+
+		doc, err := client.Atomic(Operation{
+			Op: AddOperation,
+			Data: &HybridResource{
+				One: &Resource{
+					Type: "foo",
+				},
+			},
+		}, Operation{
+			Op: RemoveOperation,
+			Ref: &Reference{
+				Type: "foo",
+				ID:   "1",
+			},
+		}, Operation{
+			Op: RemoveOperation,
+			Ref: &Reference{
+				Type: "foo",
+				ID:   "2",
+			},
+		})
+		assert.NotNil(t, doc)
+		assert.Equal(t, &Error{
+			Status: http.StatusNotFound,
+			Title:  "not found",
+			Detail: "unknown resource",
+			Source: &ErrorSource{
+				Pointer: "/atomic:operations/2",
+			},
+		}, err)
+		list, err := server.Store.Resources()
+		assert.NoError(t, err)
+		assert.Equal(t, []*Resource{
+			{Type: "foo", ID: "1"},
+		}, list)
+
+		res := &Resource{Type: "foo"}
+		seed(server.Store, res)
+		assert.Equal(t, "s-1", res.ID)
+	})
+}
+
+func TestServerAtomicOperationsRelationships(t *testing.T) {
+	withServer(func(client *Client, server *Server) {
+		doc, err := client.Atomic(Operation{
+			Op: AddOperation,
+			Data: &HybridResource{
+				One: &Resource{Type: "users", ID: "1"},
+			},
+		}, Operation{
+			Op: AddOperation,
+			Data: &HybridResource{
+				One: &Resource{Type: "users", LID: "a"},
+			},
+		}, Operation{
+			Op: AddOperation,
+			Data: &HybridResource{
+				One: &Resource{
+					Type: "posts",
+					ID:   "1",
+					Relationships: map[string]*Relationship{
+						"readers": {
+							Data: &HybridResource{
+								Many: []*Resource{{Type: "users", ID: "1"}},
+							},
+						},
+					},
+				},
+			},
+		}, Operation{
+			Op: UpdateOperation,
+			Ref: &Reference{
+				Type:         "posts",
+				ID:           "1",
+				Relationship: "author",
+			},
+			Data: &HybridResource{
+				One: &Resource{Type: "users", LID: "a"},
+			},
+		}, Operation{
+			Op: AddOperation,
+			Ref: &Reference{
+				Type:         "posts",
+				ID:           "1",
+				Relationship: "readers",
+			},
+			Data: &HybridResource{
+				Many: []*Resource{{Type: "users", LID: "a"}},
+			},
+		}, Operation{
+			Op:   RemoveOperation,
+			Href: "/posts/1/relationships/readers",
+			Data: &HybridResource{
+				Many: []*Resource{{Type: "users", ID: "1"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, doc.Results, 6)
+		assert.Equal(t, &Result{}, doc.Results[3])
+
+		doc, err = client.Find("posts", "1")
+		assert.NoError(t, err)
+		assert.Equal(t, &HybridResource{
+			One: &Resource{Type: "users", ID: "s-1"},
+		}, doc.Data.One.Relationships["author"].Data)
+		assert.Equal(t, &HybridResource{
+			Many: []*Resource{{Type: "users", ID: "s-1"}},
+		}, doc.Data.One.Relationships["readers"].Data)
+
+		_, err = client.Atomic(Operation{
+			Op: AddOperation,
+			Ref: &Reference{
+				Type:         "posts",
+				ID:           "1",
+				Relationship: "author",
+			},
+			Data: &HybridResource{
+				Many: []*Resource{{Type: "users", ID: "1"}},
+			},
+		})
+		assert.Equal(t, &Error{
+			Status: http.StatusBadRequest,
+			Title:  "bad request",
+			Detail: "expected to-many relationship",
+			Source: &ErrorSource{
+				Pointer: "/atomic:operations/0",
+			},
+		}, err)
+	})
+}
+
+func TestServerAtomicOperationsLocalIDs(t *testing.T) {
+	withServer(func(client *Client, server *Server) {
+		doc, err := client.Atomic(Operation{
+			Op: AddOperation,
+			Data: &HybridResource{
+				One: &Resource{
+					Type: "users",
+					LID:  "a",
+				},
+			},
+		}, Operation{
+			Op: AddOperation,
+			Data: &HybridResource{
+				One: &Resource{
+					Type: "posts",
+					Relationships: map[string]*Relationship{
+						"author": {
+							Data: &HybridResource{
+								One: &Resource{Type: "users", LID: "a"},
+							},
+						},
+					},
+				},
+			},
+		}, Operation{
+			Op: UpdateOperation,
+			Ref: &Reference{
+				Type: "users",
+				LID:  "a",
+			},
+			Data: &HybridResource{
+				One: &Resource{
+					Type: "users",
+					LID:  "a",
+					Attributes: Map{
+						"name": "Joe",
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, doc.Results, 3)
+		assert.Equal(t, &Resource{Type: "users", ID: "s-1", Links: &ResourceLinks{Self: &Link{Href: "/users/s-1"}}}, doc.Results[0].Data.One)
+		assert.Equal(t, "s-1", doc.Results[1].Data.One.Relationships["author"].Data.One.ID)
+		assert.Equal(t, &Resource{
+			Type: "users",
+			ID:   "s-1",
+			Attributes: Map{
+				"name": "Joe",
+			},
+		}, find(server.Store, "users", "s-1"))
+
+		_, err = client.Atomic(Operation{
+			Op: RemoveOperation,
+			Ref: &Reference{
+				Type: "users",
+				LID:  "a",
+			},
+		})
+		assert.Equal(t, &Error{
+			Status: http.StatusBadRequest,
+			Title:  "bad request",
+			Detail: "unknown local id",
+			Source: &ErrorSource{
+				Pointer: "/atomic:operations/0",
+			},
+		}, err)
+	})
+}
+
+func TestServerLocalIDSelfReference(t *testing.T) {
+	withServer(func(client *Client, server *Server) {
+		self := func() *Resource {
+			return &Resource{
+				Type: "users",
+				LID:  "a",
+				Relationships: map[string]*Relationship{
+					"manager": {
+						Data: &HybridResource{
+							One: &Resource{Type: "users", LID: "a"},
+						},
+					},
+				},
+			}
+		}
+
+		doc, err := client.Atomic(Operation{
+			Op:   AddOperation,
+			Data: &HybridResource{One: self()},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "s-1", doc.Results[0].Data.One.ID)
+		assert.Equal(t, "s-1", doc.Results[0].Data.One.Relationships["manager"].Data.One.ID)
+
+		doc, err = client.Create(self())
+		assert.NoError(t, err)
+		assert.Equal(t, "s-2", doc.Data.One.ID)
+		assert.Equal(t, "s-2", doc.Data.One.Relationships["manager"].Data.One.ID)
+
+		doc, err = client.Find("users", "s-2")
+		assert.NoError(t, err)
+		assert.Equal(t, &Resource{Type: "users", ID: "s-2"}, doc.Data.One.Relationships["manager"].Data.One)
+
+		res := self()
+		res.Relationships["manager"].Data.One.LID = "b"
+		_, err = client.Create(res)
+		assert.Equal(t, BadRequest("unknown local id"), err)
+		assert.Equal(t, 2, count(server.Store, "users"))
+	})
+}
+
+func TestServerActions(t *testing.T) {
+	server := NewServer(ServerConfig{
+		Types: []string{"posts"},
+		CollectionActions: map[string]map[string]ActionHandler{
+			"count": {
+				"GET": func(req *Request, body []byte, store Store) (*Document, error) {
+					list, err := store.List(req)
+					if err != nil {
+						return nil, err
+					}
+					return &Document{
+						Meta: Map{"count": len(list)},
+					}, nil
+				},
+			},
+		},
+		ResourceActions: map[string]map[string]ActionHandler{
+			"publish": {
+				"POST": func(req *Request, body []byte, store Store) (*Document, error) {
+					res, err := store.Find(req.ResourceType, req.ResourceID)
+					if err != nil {
+						return nil, err
+					}
+					res.Attributes["published"] = string(body)
+					return nil, store.Update(res)
+				},
+			},
+		},
+	})
+
+	seed(server.Store, &Resource{Type: "posts", ID: "1", Attributes: Map{}})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := serve("GET", "/posts/count", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"meta": {
+			"count": 1
+		}
+	}`, rec.Body.String())
+
+	rec = serve("POST", "/posts/1/publish", "now")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "now", find(server.Store, "posts", "1").Attributes["published"])
+
+	rec = serve("POST", "/posts/2/publish", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serve("POST", "/posts/count", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET", rec.Header().Get("Allow"))
+
+	rec = serve("DELETE", "/posts/1/publish", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "POST", rec.Header().Get("Allow"))
+
+	rec = serve("PATCH", "/posts/count", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET", rec.Header().Get("Allow"))
+
+	rec = serve("POST", "/users/count", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Empty(t, rec.Header().Get("Allow"))
+
+	server.Parser.ResourceActions["publish"] = []string{"DELETE", "POST"}
+
+	rec = serve("DELETE", "/posts/1/publish", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "POST", rec.Header().Get("Allow"))
+}
+
+func TestServerLoadDump(t *testing.T) {
+	withServer(func(client *Client, server *Server) {
+		err := server.Load(strings.NewReader(`{
+			"data": [
+				{
+					"type": "posts",
+					"id": "s-2",
+					"attributes": {
+						"title": "Hello"
+					},
+					"relationships": {
+						"author": {
+							"data": { "type": "users", "id": "1" },
+							"links": { "related": "/posts/s-2/author" }
+						}
+					}
+				}
+			],
+			"included": [
+				{
+					"type": "users",
+					"id": "1",
+					"attributes": {
+						"name": "Joe"
+					}
+				}
+			]
+		}`))
+		assert.NoError(t, err)
+
+		doc, err := client.Find("posts", "s-2", Request{Include: []string{"author"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello", doc.Data.One.Attributes["title"])
+		assert.Len(t, doc.Included, 1)
+		assert.Equal(t, "Joe", doc.Included[0].Attributes["name"])
+
+		doc, err = client.Create(&Resource{Type: "posts"})
+		assert.NoError(t, err)
+		assert.Equal(t, "s-3", doc.Data.One.ID)
+
+		var buf strings.Builder
+		err = server.Dump(&buf)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"data": [
+				{
+					"type": "posts",
+					"id": "s-2",
+					"attributes": {
+						"title": "Hello"
+					},
+					"relationships": {
+						"author": {
+							"data": { "type": "users", "id": "1" }
+						}
+					}
+				},
+				{
+					"type": "posts",
+					"id": "s-3"
+				},
+				{
+					"type": "users",
+					"id": "1",
+					"attributes": {
+						"name": "Joe"
+					}
+				}
+			]
+		}`, buf.String())
+
+		other := NewServer(ServerConfig{})
+		err = other.Load(strings.NewReader(buf.String()))
+		assert.NoError(t, err)
+		list, err := server.Store.Resources()
+		assert.NoError(t, err)
+		otherList, err := other.Store.Resources()
+		assert.NoError(t, err)
+		assert.Equal(t, list, otherList)
+
+		res := &Resource{Type: "posts"}
+		seed(other.Store, res)
+		assert.Equal(t, "s-4", res.ID)
+
+		err = other.Load(strings.NewReader(`{
+			"data": [
+				{ "type": "users", "id": "2" },
+				{ "type": "users" }
+			]
+		}`))
+		assert.Equal(t, BadRequest("missing resource id"), err)
+		assert.Equal(t, 1, count(other.Store, "users"))
+
+		err = other.Load(strings.NewReader(`{
+			"data": [
+				{ "type": "users", "id": "2" },
+				{ "type": "users", "id": "1" }
+			]
+		}`))
+		assert.Equal(t, BadRequest("conflicting resource users/1, no resources have been loaded"), err)
+		assert.Equal(t, 1, count(other.Store, "users"))
+	})
+}
+
+func TestServerLoadDumpDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonapi")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	server := NewServer(ServerConfig{})
+	seed(server.Store, &Resource{
+		Type:       "posts",
+		ID:         "1",
+		Attributes: Map{"title": "Hello"},
+		Relationships: map[string]*Relationship{
+			"author": {
+				Data: &HybridResource{One: &Resource{Type: "users", ID: "1"}},
+			},
+		},
+	}, &Resource{
+		Type: "posts",
+		ID:   "2",
+	}, &Resource{
+		Type:       "users",
+		ID:         "1",
+		Attributes: Map{"name": "Joe"},
+	})
+
+	err = server.DumpDir(dir)
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "posts.json"),
+		filepath.Join(dir, "users.json"),
+	}, files)
+
+	other := NewServer(ServerConfig{})
+	err = other.LoadDir(dir)
+	assert.NoError(t, err)
+
+	list, err := server.Store.Resources()
+	assert.NoError(t, err)
+	otherList, err := other.Store.Resources()
+	assert.NoError(t, err)
+	assert.Equal(t, list, otherList)
+
+	err = other.LoadDir(dir)
+	assert.Equal(t, BadRequest("conflicting resource posts/1, no resources have been loaded"), err)
+	assert.Equal(t, 2, count(other.Store, "posts"))
+
+	err = os.Rename(filepath.Join(dir, "users.json"), filepath.Join(dir, "tags.json"))
+	assert.NoError(t, err)
+
+	other = NewServer(ServerConfig{})
+	err = other.LoadDir(dir)
+	assert.Equal(t, BadRequest("mismatching resource type users in file tags.json, no resources have been loaded"), err)
+	assert.Equal(t, 0, count(other.Store, "posts"))
+	assert.Equal(t, 0, count(other.Store, "users"))
+}
+
+func TestServerJSONAPI(t *testing.T) {
+	withServer(func(client *Client, server *Server) {
+		res, err := client.Perform(Request{
+			Intent:       AtomicOperations,
+			ResourceType: "operations",
+			Extensions:   []string{AtomicExtension},
+		}, &Document{
+			Operations: []*Operation{
+				{
+					Op: AddOperation,
+					Data: &HybridResource{
+						One: &Resource{Type: "foo", ID: "1"},
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.Equal(t, []string{AtomicExtension}, res.Extensions)
+		assert.Empty(t, res.Profiles)
+		assert.Nil(t, res.Document.JSONAPI)
+
+		server.Config.JSONAPI = &JSONAPI{Version: "1.1"}
+
+		doc, err := client.Find("foo", "1")
+		assert.NoError(t, err)
+		assert.Equal(t, &JSONAPI{Version: "1.1"}, doc.JSONAPI)
 	})
 }
@@ -0,0 +1,64 @@
+package jsonapi
+
+import "strings"
+
+// A SortField is a single field of the requested sorting.
+type SortField struct {
+	// The name of the field.
+	Name string
+
+	// Whether the field is sorted in descending order.
+	Descending bool
+}
+
+// ParseSortField will parse a sort field from the passed string e.g. "-title".
+func ParseSortField(str string) SortField {
+	return SortField{
+		Name:       strings.TrimPrefix(str, "-"),
+		Descending: strings.HasPrefix(str, "-"),
+	}
+}
+
+// String will return the string representation of the sort field.
+func (f SortField) String() string {
+	if f.Descending {
+		return "-" + f.Name
+	}
+
+	return f.Name
+}
+
+// SortFields will return the parsed sorting of the request.
+func (r *Request) SortFields() []SortField {
+	// check sorting
+	if len(r.Sorting) == 0 {
+		return nil
+	}
+
+	// parse fields
+	fields := make([]SortField, 0, len(r.Sorting))
+	for _, str := range r.Sorting {
+		fields = append(fields, ParseSortField(str))
+	}
+
+	return fields
+}
+
+// SetSortFields will set the sorting of the request to the passed fields.
+func (r *Request) SetSortFields(fields ...SortField) {
+	// reset sorting
+	r.Sorting = nil
+
+	// add fields
+	for _, field := range fields {
+		r.Sorting = append(r.Sorting, field.String())
+	}
+}
+
+func sortValue(res *Resource, name string) interface{} {
+	if name == "id" {
+		return res.ID
+	}
+
+	return res.Attributes[name]
+}
@@ -0,0 +1,25 @@
+package jsonapi
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestSortFields(t *testing.T) {
+	req := &Request{}
+	assert.Nil(t, req.SortFields())
+
+	req.Sorting = []string{"-created", "title"}
+	assert.Equal(t, []SortField{
+		{Name: "created", Descending: true},
+		{Name: "title"},
+	}, req.SortFields())
+
+	req.SetSortFields(SortField{Name: "title", Descending: true}, SortField{Name: "id"})
+	assert.Equal(t, []string{"-title", "id"}, req.Sorting)
+	assert.Equal(t, url.Values{
+		"sort": []string{"-title,id"},
+	}, req.Query())
+}
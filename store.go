@@ -0,0 +1,772 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A Store is used by a server to store resources. Returned resources may be
+// modified by the caller without affecting the stored resources.
+type Store interface {
+	// Find will return the specified resource or a "Not Found" error.
+	Find(typ, id string) (*Resource, error)
+
+	// List will return the resources of the requested type that match the
+	// filters, conditions and search of the request, sorted by the requested
+	// sorting and their id.
+	List(req *Request) ([]*Resource, error)
+
+	// Create will store a new resource. An id is assigned if missing.
+	Create(res *Resource) error
+
+	// Update will merge the attributes, relationships and meta of the passed
+	// resource into an existing resource. Missing members are kept.
+	Update(res *Resource) error
+
+	// Delete will remove an existing resource.
+	Delete(typ, id string) error
+
+	// SetRelationship will replace a relationship of an existing resource.
+	SetRelationship(typ, id, name string, rel *Relationship) error
+
+	// Resources will return all stored resources sorted by type and id.
+	Resources() ([]*Resource, error)
+
+	// Transaction will call the function with a store that performs all
+	// operations atomically. All changes are rolled back if an error is
+	// returned.
+	Transaction(fn func(Store) error) error
+}
+
+// MemoryStore is an in-memory store that is used by default. Operations lock
+// the affected collection only, transactions lock the whole store.
+//
+// The zero value is ready to use.
+type MemoryStore struct {
+	// The stored resources keyed by type and id.
+	//
+	// Note: The data must not be accessed directly while the store is in use.
+	Data map[string]map[string]*Resource
+
+	// The counter used to generate ids.
+	Counter int
+
+	tx    sync.RWMutex
+	mutex sync.Mutex
+	locks map[string]*sync.RWMutex
+}
+
+// NewMemoryStore will create and return a new memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		Data: map[string]map[string]*Resource{},
+	}
+}
+
+// Find implements the Store interface.
+func (s *MemoryStore) Find(typ, id string) (*Resource, error) {
+	defer s.lock(typ, false)()
+	return s.find(typ, id)
+}
+
+// List implements the Store interface.
+func (s *MemoryStore) List(req *Request) ([]*Resource, error) {
+	defer s.lock(req.ResourceType, false)()
+	return s.list(req), nil
+}
+
+// Create implements the Store interface.
+func (s *MemoryStore) Create(res *Resource) error {
+	defer s.lock(res.Type, true)()
+	return s.create(res)
+}
+
+// Update implements the Store interface.
+func (s *MemoryStore) Update(res *Resource) error {
+	defer s.lock(res.Type, true)()
+	return s.update(res)
+}
+
+// Delete implements the Store interface.
+func (s *MemoryStore) Delete(typ, id string) error {
+	defer s.lock(typ, true)()
+	return s.delete(typ, id)
+}
+
+// SetRelationship implements the Store interface.
+func (s *MemoryStore) SetRelationship(typ, id, name string, rel *Relationship) error {
+	defer s.lock(typ, true)()
+	return s.setRelationship(typ, id, name, rel)
+}
+
+// Resources implements the Store interface.
+func (s *MemoryStore) Resources() ([]*Resource, error) {
+	// acquire store
+	s.tx.Lock()
+	defer s.tx.Unlock()
+
+	return s.resources(), nil
+}
+
+// Transaction implements the Store interface.
+func (s *MemoryStore) Transaction(fn func(Store) error) error {
+	// acquire store
+	s.tx.Lock()
+	defer s.tx.Unlock()
+
+	// take snapshot
+	data, counter := s.snapshot()
+
+	// call function
+	err := fn(&memoryTransaction{store: s})
+	if err != nil {
+		// roll back
+		s.Data = data
+		s.Counter = counter
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) lock(typ string, write bool) func() {
+	// acquire store
+	s.tx.RLock()
+
+	// get collection lock
+	s.mutex.Lock()
+	if s.locks == nil {
+		s.locks = map[string]*sync.RWMutex{}
+	}
+	lock := s.locks[typ]
+	if lock == nil {
+		lock = &sync.RWMutex{}
+		s.locks[typ] = lock
+	}
+	s.mutex.Unlock()
+
+	// acquire collection
+	if write {
+		lock.Lock()
+		return func() {
+			lock.Unlock()
+			s.tx.RUnlock()
+		}
+	}
+
+	lock.RLock()
+	return func() {
+		lock.RUnlock()
+		s.tx.RUnlock()
+	}
+}
+
+func (s *MemoryStore) collection(typ string, create bool) map[string]*Resource {
+	// acquire mutex
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// get collection
+	coll := s.Data[typ]
+	if coll == nil && create {
+		if s.Data == nil {
+			s.Data = map[string]map[string]*Resource{}
+		}
+		coll = map[string]*Resource{}
+		s.Data[typ] = coll
+	}
+
+	return coll
+}
+
+func (s *MemoryStore) find(typ, id string) (*Resource, error) {
+	// get resource
+	res := s.collection(typ, false)[id]
+	if res == nil {
+		return nil, NotFound("unknown resource")
+	}
+
+	return cloneResource(res), nil
+}
+
+func (s *MemoryStore) list(req *Request) []*Resource {
+	// collect matching resources
+	list := []*Resource{}
+	for _, res := range s.collection(req.ResourceType, false) {
+		if matchesRequest(res, req) {
+			list = append(list, cloneResource(res))
+		}
+	}
+
+	// sort list
+	sortResources(list, req.SortFields())
+
+	return list
+}
+
+func (s *MemoryStore) create(res *Resource) error {
+	// get collection
+	coll := s.collection(res.Type, true)
+
+	// check existence
+	if res.ID != "" && coll[res.ID] != nil {
+		return BadRequest("conflicting resource")
+	}
+
+	// assign id or advance counter past generated ids to avoid collisions
+	s.mutex.Lock()
+	if res.ID == "" {
+		s.Counter++
+		res.ID = "s-" + strconv.Itoa(s.Counter)
+	} else if strings.HasPrefix(res.ID, "s-") {
+		n, err := strconv.Atoi(strings.TrimPrefix(res.ID, "s-"))
+		if err == nil && n > s.Counter {
+			s.Counter = n
+		}
+	}
+	s.mutex.Unlock()
+
+	// store resource
+	coll[res.ID] = cloneResource(res)
+
+	return nil
+}
+
+func (s *MemoryStore) update(res *Resource) error {
+	// get collection
+	coll := s.collection(res.Type, false)
+
+	// get existing resource
+	existing := coll[res.ID]
+	if existing == nil {
+		return NotFound("unknown resource")
+	}
+
+	// store merged resource
+	coll[res.ID] = mergeResource(existing, cloneResource(res))
+
+	return nil
+}
+
+func (s *MemoryStore) delete(typ, id string) error {
+	// get collection
+	coll := s.collection(typ, false)
+
+	// check existence
+	if coll[id] == nil {
+		return NotFound("unknown resource")
+	}
+
+	// delete resource
+	delete(coll, id)
+
+	return nil
+}
+
+func (s *MemoryStore) setRelationship(typ, id, name string, rel *Relationship) error {
+	// get collection
+	coll := s.collection(typ, false)
+
+	// check existence
+	res := coll[id]
+	if res == nil {
+		return NotFound("unknown resource")
+	}
+
+	// replace resource, stored resources are never modified
+	res = copyResource(res)
+	if res.Relationships == nil {
+		res.Relationships = map[string]*Relationship{}
+	}
+	cpy := *rel
+	res.Relationships[name] = &cpy
+	coll[id] = res
+
+	return nil
+}
+
+func (s *MemoryStore) resources() []*Resource {
+	// collect resources
+	list := []*Resource{}
+	for _, coll := range s.Data {
+		for _, res := range coll {
+			list = append(list, cloneResource(res))
+		}
+	}
+
+	// sort resources
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Type != list[j].Type {
+			return list[i].Type < list[j].Type
+		}
+		return list[i].ID < list[j].ID
+	})
+
+	return list
+}
+
+func (s *MemoryStore) snapshot() (map[string]map[string]*Resource, int) {
+	// copy collections, resources are replaced rather than modified
+	data := make(map[string]map[string]*Resource, len(s.Data))
+	for typ, coll := range s.Data {
+		cpy := make(map[string]*Resource, len(coll))
+		for id, res := range coll {
+			cpy[id] = res
+		}
+		data[typ] = cpy
+	}
+
+	return data, s.Counter
+}
+
+// memoryTransaction performs the operations of a transaction without locking
+// as the whole store is already locked.
+type memoryTransaction struct {
+	store *MemoryStore
+}
+
+func (t *memoryTransaction) Find(typ, id string) (*Resource, error) {
+	return t.store.find(typ, id)
+}
+
+func (t *memoryTransaction) List(req *Request) ([]*Resource, error) {
+	return t.store.list(req), nil
+}
+
+func (t *memoryTransaction) Create(res *Resource) error {
+	return t.store.create(res)
+}
+
+func (t *memoryTransaction) Update(res *Resource) error {
+	return t.store.update(res)
+}
+
+func (t *memoryTransaction) Delete(typ, id string) error {
+	return t.store.delete(typ, id)
+}
+
+func (t *memoryTransaction) SetRelationship(typ, id, name string, rel *Relationship) error {
+	return t.store.setRelationship(typ, id, name, rel)
+}
+
+func (t *memoryTransaction) Resources() ([]*Resource, error) {
+	return t.store.resources(), nil
+}
+
+func (t *memoryTransaction) Transaction(fn func(Store) error) error {
+	return fn(t)
+}
+
+// FileStore is a memory store that persists its resources as a JSON snapshot
+// to a file after every change. The snapshot is a document with all resources
+// as primary data and the id counter as meta.
+type FileStore struct {
+	*MemoryStore
+
+	// The path of the snapshot file.
+	Path string
+}
+
+// OpenFileStore will create and return a new file store that is initialized
+// from the snapshot at the specified path if it exists.
+func OpenFileStore(path string) (*FileStore, error) {
+	// prepare store
+	store := &FileStore{
+		MemoryStore: NewMemoryStore(),
+		Path:        path,
+	}
+
+	// read snapshot
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// decode snapshot
+	var doc Document
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err = dec.Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// add resources
+	if doc.Data != nil {
+		for _, res := range doc.Data.Many {
+			err = store.MemoryStore.create(res)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// set counter
+	if counter, ok := toFloat(doc.Meta["counter"]); ok {
+		store.Counter = int(counter)
+	}
+
+	return store, nil
+}
+
+// Create implements the Store interface.
+func (s *FileStore) Create(res *Resource) error {
+	return s.Transaction(func(tx Store) error {
+		return tx.Create(res)
+	})
+}
+
+// Update implements the Store interface.
+func (s *FileStore) Update(res *Resource) error {
+	return s.Transaction(func(tx Store) error {
+		return tx.Update(res)
+	})
+}
+
+// Delete implements the Store interface.
+func (s *FileStore) Delete(typ, id string) error {
+	return s.Transaction(func(tx Store) error {
+		return tx.Delete(typ, id)
+	})
+}
+
+// SetRelationship implements the Store interface.
+func (s *FileStore) SetRelationship(typ, id, name string, rel *Relationship) error {
+	return s.Transaction(func(tx Store) error {
+		return tx.SetRelationship(typ, id, name, rel)
+	})
+}
+
+// Transaction implements the Store interface. The snapshot is written before
+// the store is unlocked. Changes are rolled back if it cannot be written.
+func (s *FileStore) Transaction(fn func(Store) error) error {
+	return s.MemoryStore.Transaction(func(tx Store) error {
+		// call function
+		err := fn(tx)
+		if err != nil {
+			return err
+		}
+
+		return s.save()
+	})
+}
+
+func (s *FileStore) save() error {
+	// encode snapshot
+	data, err := json.MarshalIndent(&Document{
+		Data: &HybridResource{Many: s.resources()},
+		Meta: Map{"counter": s.Counter},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write temporary file
+	file, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Close()
+	} else {
+		_ = file.Close()
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+
+	// replace file
+	err = os.Rename(file.Name(), s.Path)
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+
+	return nil
+}
+
+// cloneResource will return a deep copy of the resource with copied maps,
+// links and relationships.
+func cloneResource(res *Resource) *Resource {
+	// copy resource
+	cpy := copyResource(res)
+
+	// copy links
+	if res.Links != nil {
+		cpy.Links = &ResourceLinks{
+			Self: cloneLink(res.Links.Self),
+		}
+	}
+
+	// copy relationships
+	for name, rel := range cpy.Relationships {
+		if rel != nil {
+			cpy.Relationships[name] = &Relationship{
+				Data:  cloneLinkage(rel.Data),
+				Links: cloneDocumentLinks(rel.Links),
+				Meta:  copyMap(rel.Meta),
+			}
+		}
+	}
+
+	return cpy
+}
+
+// mergeResource will merge the attributes, relationships and meta of the update
+// into a copy of the existing resource.
+func mergeResource(existing, update *Resource) *Resource {
+	// copy resource
+	res := copyResource(existing)
+
+	// merge attributes
+	for name, value := range update.Attributes {
+		if res.Attributes == nil {
+			res.Attributes = Map{}
+		}
+		res.Attributes[name] = value
+	}
+
+	// merge relationships
+	for name, rel := range update.Relationships {
+		if res.Relationships == nil {
+			res.Relationships = map[string]*Relationship{}
+		}
+		res.Relationships[name] = rel
+	}
+
+	// merge meta
+	for name, value := range update.Meta {
+		if res.Meta == nil {
+			res.Meta = Map{}
+		}
+		res.Meta[name] = value
+	}
+
+	// replace links
+	if update.Links != nil {
+		res.Links = update.Links
+	}
+
+	return res
+}
+
+func cloneLinkage(data *HybridResource) *HybridResource {
+	// check data
+	if data == nil {
+		return nil
+	}
+
+	// copy identifiers
+	cpy := &HybridResource{}
+	if data.One != nil {
+		cpy.One = copyResource(data.One)
+	}
+	if data.Many != nil {
+		cpy.Many = make([]*Resource, 0, len(data.Many))
+		for _, id := range data.Many {
+			cpy.Many = append(cpy.Many, copyResource(id))
+		}
+	}
+
+	return cpy
+}
+
+func cloneDocumentLinks(links *DocumentLinks) *DocumentLinks {
+	// check links
+	if links == nil {
+		return nil
+	}
+
+	return &DocumentLinks{
+		Self:        cloneLink(links.Self),
+		Related:     cloneLink(links.Related),
+		DescribedBy: cloneLink(links.DescribedBy),
+		First:       cloneLink(links.First),
+		Previous:    cloneLink(links.Previous),
+		Next:        cloneLink(links.Next),
+		Last:        cloneLink(links.Last),
+	}
+}
+
+func cloneLink(link *Link) *Link {
+	// check link
+	if link == nil {
+		return nil
+	}
+
+	// copy link
+	cpy := *link
+	cpy.DescribedBy = cloneLink(link.DescribedBy)
+	cpy.HrefLang = append([]string(nil), link.HrefLang...)
+	cpy.Meta = copyMap(link.Meta)
+
+	return &cpy
+}
+
+func copyMap(m Map) Map {
+	// check map
+	if m == nil {
+		return nil
+	}
+
+	// copy map
+	cpy := make(Map, len(m))
+	for key, value := range m {
+		cpy[key] = value
+	}
+
+	return cpy
+}
+
+func matchesRequest(res *Resource, req *Request) bool {
+	// check filters
+	for name, values := range req.Filters {
+		if !matchesFilter(res, name, values) {
+			return false
+		}
+	}
+
+	// check conditions
+	for _, cond := range req.Conditions {
+		if !matchesCondition(res, cond) {
+			return false
+		}
+	}
+
+	// check search
+	if req.Search != "" {
+		return matchesSearch(res, req.Search)
+	}
+
+	return true
+}
+
+func sortResources(list []*Resource, fields []SortField) {
+	sort.Slice(list, func(i, j int) bool {
+		for _, field := range fields {
+			// compare values
+			res := compareValues(sortValue(list[i], field.Name), sortValue(list[j], field.Name))
+			if field.Descending {
+				res = -res
+			}
+
+			// check result
+			if res != 0 {
+				return res < 0
+			}
+		}
+
+		return list[i].ID < list[j].ID
+	})
+}
+
+func matchesFilter(res *Resource, name string, values []string) bool {
+	// get value
+	var value string
+	if name == "id" {
+		value = res.ID
+	} else if v, ok := res.Attributes[name]; ok && v != nil {
+		value = fmt.Sprint(v)
+	} else {
+		return false
+	}
+
+	return contains(values, value)
+}
+
+func matchesCondition(res *Resource, cond Condition) bool {
+	// get value
+	value := sortValue(res, cond.Path[0])
+	for _, segment := range cond.Path[1:] {
+		switch m := value.(type) {
+		case Map:
+			value = m[segment]
+		case map[string]interface{}:
+			value = m[segment]
+		default:
+			value = nil
+		}
+	}
+
+	// check value
+	if value == nil || len(cond.Values) == 0 {
+		return false
+	}
+
+	// compare value
+	str := fmt.Sprint(value)
+	switch cond.Operator {
+	case EqualOperator, "":
+		return str == cond.Values[0]
+	case NotEqualOperator:
+		return str != cond.Values[0]
+	case GreaterThanOperator:
+		return compareValues(value, json.Number(cond.Values[0])) > 0
+	case GreaterThanOrEqualOperator:
+		return compareValues(value, json.Number(cond.Values[0])) >= 0
+	case LessThanOperator:
+		return compareValues(value, json.Number(cond.Values[0])) < 0
+	case LessThanOrEqualOperator:
+		return compareValues(value, json.Number(cond.Values[0])) <= 0
+	case LikeOperator:
+		return matchesLike(str, cond.Values[0])
+	case InOperator:
+		return contains(cond.Values, str)
+	}
+
+	return false
+}
+
+func matchesLike(str, pattern string) bool {
+	// split pattern
+	parts := strings.Split(pattern, "*")
+
+	// check prefix
+	if !strings.HasPrefix(str, parts[0]) {
+		return false
+	}
+	str = str[len(parts[0]):]
+
+	// check single part
+	if len(parts) == 1 {
+		return str == ""
+	}
+
+	// check middle parts
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(str, part)
+		if i < 0 {
+			return false
+		}
+		str = str[i+len(part):]
+	}
+
+	return strings.HasSuffix(str, parts[len(parts)-1])
+}
+
+func matchesSearch(res *Resource, query string) bool {
+	// prepare query
+	query = strings.ToLower(query)
+
+	// check string attributes
+	for _, value := range res.Attributes {
+		if str, ok := value.(string); ok && strings.Contains(strings.ToLower(str), query) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,229 @@
+package jsonapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+
+	res := &Resource{Type: "foo", Attributes: Map{"bar": "baz"}}
+	err := store.Create(res)
+	assert.NoError(t, err)
+	assert.Equal(t, "s-1", res.ID)
+
+	err = store.Create(&Resource{Type: "foo", ID: "s-1"})
+	assert.Equal(t, BadRequest("conflicting resource"), err)
+
+	found, err := store.Find("foo", "s-1")
+	assert.NoError(t, err)
+	assert.Equal(t, res, found)
+
+	found.Attributes["bar"] = "qux"
+	found, err = store.Find("foo", "s-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", found.Attributes["bar"])
+
+	err = store.SetRelationship("foo", "s-1", "bar", &Relationship{
+		Data: &HybridResource{One: &Resource{Type: "bar", ID: "1"}},
+	})
+	assert.NoError(t, err)
+
+	list, err := store.List(&Request{ResourceType: "foo"})
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "1", list[0].Relationships["bar"].Data.One.ID)
+
+	err = store.Update(&Resource{Type: "foo", ID: "s-1", Attributes: Map{"qux": "quz"}})
+	assert.NoError(t, err)
+
+	found, err = store.Find("foo", "s-1")
+	assert.NoError(t, err)
+	assert.Equal(t, Map{"bar": "baz", "qux": "quz"}, found.Attributes)
+	assert.Equal(t, "1", found.Relationships["bar"].Data.One.ID)
+
+	err = store.Update(&Resource{Type: "foo", ID: "s-2"})
+	assert.Equal(t, NotFound("unknown resource"), err)
+
+	err = store.Delete("foo", "s-1")
+	assert.NoError(t, err)
+
+	_, err = store.Find("foo", "s-1")
+	assert.Equal(t, NotFound("unknown resource"), err)
+}
+
+func TestMemoryStoreClone(t *testing.T) {
+	store := NewMemoryStore()
+
+	err := store.Create(&Resource{
+		Type:  "foo",
+		ID:    "1",
+		Links: &ResourceLinks{Self: &Link{Href: "/foo/1"}},
+		Relationships: map[string]*Relationship{
+			"bar": {
+				Data:  &HybridResource{Many: []*Resource{{Type: "bar", ID: "1"}}},
+				Links: &DocumentLinks{Self: &Link{Href: "/foo/1/relationships/bar"}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	res, err := store.Find("foo", "1")
+	assert.NoError(t, err)
+	res.Links.Self.Href = "x"
+	res.Relationships["bar"].Data.Many[0].ID = "x"
+	res.Relationships["bar"].Data.Many = append(res.Relationships["bar"].Data.Many, &Resource{Type: "bar", ID: "2"})
+	res.Relationships["bar"].Links.Self.Href = "x"
+
+	res, err = store.Find("foo", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Resource{
+		Type:  "foo",
+		ID:    "1",
+		Links: &ResourceLinks{Self: &Link{Href: "/foo/1"}},
+		Relationships: map[string]*Relationship{
+			"bar": {
+				Data:  &HybridResource{Many: []*Resource{{Type: "bar", ID: "1"}}},
+				Links: &DocumentLinks{Self: &Link{Href: "/foo/1/relationships/bar"}},
+			},
+		},
+	}, res)
+}
+
+func TestMemoryStoreTransaction(t *testing.T) {
+	store := NewMemoryStore()
+
+	err := store.Create(&Resource{Type: "foo", ID: "1"})
+	assert.NoError(t, err)
+
+	err = store.Transaction(func(tx Store) error {
+		err := tx.Create(&Resource{Type: "foo"})
+		assert.NoError(t, err)
+
+		err = tx.Delete("foo", "1")
+		assert.NoError(t, err)
+
+		return tx.Delete("foo", "2")
+	})
+	assert.Equal(t, NotFound("unknown resource"), err)
+	assert.Equal(t, map[string]map[string]*Resource{
+		"foo": {
+			"1": {Type: "foo", ID: "1"},
+		},
+	}, store.Data)
+	assert.Equal(t, 0, store.Counter)
+}
+
+func TestMemoryStoreConcurrency(t *testing.T) {
+	store := NewMemoryStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			typ := "foo" + strconv.Itoa(i%2)
+			for j := 0; j < 10; j++ {
+				res := &Resource{Type: typ}
+				assert.NoError(t, store.Create(res))
+				_, err := store.Find(typ, res.ID)
+				assert.NoError(t, err)
+				_, err = store.List(&Request{ResourceType: typ})
+				assert.NoError(t, err)
+				assert.NoError(t, store.Transaction(func(tx Store) error {
+					return tx.Update(res)
+				}))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, store.Data["foo0"], 50)
+	assert.Len(t, store.Data["foo1"], 50)
+	assert.Equal(t, 100, store.Counter)
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonapi")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "store.json")
+
+	store, err := OpenFileStore(path)
+	assert.NoError(t, err)
+
+	err = store.Create(&Resource{Type: "foo", Attributes: Map{"bar": "baz"}})
+	assert.NoError(t, err)
+
+	err = store.Create(&Resource{
+		Type: "bar",
+		ID:   "1",
+		Relationships: map[string]*Relationship{
+			"foo": {
+				Data: &HybridResource{One: &Resource{Type: "foo", ID: "s-1"}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	err = store.Transaction(func(tx Store) error {
+		err := tx.Delete("bar", "1")
+		assert.NoError(t, err)
+		return BadRequest("rollback")
+	})
+	assert.Error(t, err)
+
+	store, err = OpenFileStore(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, store.Counter)
+
+	res, err := store.Find("foo", "s-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", res.Attributes["bar"])
+
+	res, err = store.Find("bar", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "s-1", res.Relationships["foo"].Data.One.ID)
+
+	res = &Resource{Type: "foo"}
+	err = store.Create(res)
+	assert.NoError(t, err)
+	assert.Equal(t, "s-2", res.ID)
+}
+
+func TestServerFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonapi")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "store.json")
+
+	withServer(func(client *Client, server *Server) {
+		server.Store, err = OpenFileStore(path)
+		assert.NoError(t, err)
+
+		_, err = client.Create(&Resource{Type: "foo", Attributes: Map{"bar": "baz"}})
+		assert.NoError(t, err)
+	})
+
+	withServer(func(client *Client, server *Server) {
+		server.Store, err = OpenFileStore(path)
+		assert.NoError(t, err)
+
+		doc, err := client.Find("foo", "s-1")
+		assert.NoError(t, err)
+		assert.Equal(t, "baz", doc.Data.One.Attributes["bar"])
+
+		_, err = client.Find("foo", "s-2")
+		assert.Equal(t, http.StatusNotFound, err.(*Error).Status)
+	})
+}
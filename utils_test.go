@@ -17,7 +17,7 @@ func withServer(cb func(client *Client, server *Server)) {
 	}
 
 	go func() {
-		err = http.Serve(socket, server)
+		err := http.Serve(socket, server)
 		if !errors.Is(err, net.ErrClosed) {
 			panic(err)
 		}
@@ -35,6 +35,31 @@ func withServer(cb func(client *Client, server *Server)) {
 	}
 }
 
+func seed(store Store, list ...*Resource) {
+	for _, res := range list {
+		err := store.Create(res)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func find(store Store, typ, id string) *Resource {
+	res, err := store.Find(typ, id)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func count(store Store, typ string) int {
+	list, err := store.List(&Request{ResourceType: typ})
+	if err != nil {
+		panic(err)
+	}
+	return len(list)
+}
+
 func unescape(str string) string {
 	str, err := url.QueryUnescape(str)
 	if err != nil {
@@ -0,0 +1,701 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Validate will check the full linkage of the document. It is a short-hand for
+// ValidateFields without sparse fieldsets.
+func (d *Document) Validate() []*Error {
+	return d.ValidateFields(nil)
+}
+
+// ValidateFields will check the full linkage of the document and return a
+// list of errors with pointers to the offending members. The following
+// problems are reported:
+//
+//   - Included resources without primary data.
+//   - Multiple resources with the same type and id or local id.
+//   - Relationship linkage that lacks a type or id and local id and cannot be
+//     resolved.
+//   - Relationship linkage with a local id that does not match a resource in
+//     the document.
+//   - Included resources that are not reachable from the primary data through
+//     relationship linkage.
+//
+// The reachability check is skipped if sparse fieldsets have been applied to
+// any of the resource types in the document, as they may hide the
+// relationships that link the included resources.
+func (d *Document) ValidateFields(fields map[string][]string) []*Error {
+	// prepare errors
+	var errs []*Error
+
+	// check included without data
+	if d.Data == nil && len(d.Included) > 0 {
+		errs = append(errs, BadRequestPointer("included resources without primary data", "/included"))
+	}
+
+	// prepare primary resources and pointers
+	var primary []*Resource
+	var pointers []string
+	if d.Data != nil && d.Data.One != nil {
+		primary = append(primary, d.Data.One)
+		pointers = append(pointers, "/data")
+	} else if d.Data != nil {
+		for i, res := range d.Data.Many {
+			primary = append(primary, res)
+			pointers = append(pointers, "/data/"+strconv.Itoa(i))
+		}
+	}
+
+	// add included resources and pointers
+	resources := append([]*Resource{}, primary...)
+	for i, res := range d.Included {
+		resources = append(resources, res)
+		pointers = append(pointers, "/included/"+strconv.Itoa(i))
+	}
+
+	// prepare index
+	index := make(map[resourceKey]int, len(resources))
+
+	// prepare duplicates
+	duplicates := make([]bool, len(resources))
+
+	// prepare sparse flag
+	sparse := false
+
+	// index resources
+	for i, res := range resources {
+		// check nil resources
+		if res == nil {
+			errs = append(errs, BadRequestPointer("missing resource", pointers[i]))
+			continue
+		}
+
+		// check sparse fieldsets
+		if _, ok := fields[res.Type]; ok {
+			sparse = true
+		}
+
+		// skip anonymous resources
+		key, ok := keyOf(res)
+		if !ok {
+			continue
+		}
+
+		// check duplicates
+		if _, ok := index[key]; ok {
+			errs = append(errs, BadRequestPointer("duplicate resource", pointers[i]))
+			duplicates[i] = true
+			continue
+		}
+
+		// add resource
+		index[key] = i
+	}
+
+	// check relationships
+	for i, res := range resources {
+		if res == nil {
+			continue
+		}
+		for _, name := range sortedRelationships(res) {
+			eachLinkage(res.Relationships[name], pointers[i]+"/relationships/"+escapePointer(name)+"/data", func(id *Resource, pointer string) {
+				if id == nil || id.Type == "" || (id.ID == "" && id.LID == "") {
+					errs = append(errs, BadRequestPointer("dangling resource reference", pointer))
+				} else if id.ID == "" {
+					if _, ok := index[resourceKey{typ: id.Type, lid: id.LID}]; !ok {
+						errs = append(errs, BadRequestPointer("unresolved local id", pointer+"/lid"))
+					}
+				}
+			})
+		}
+	}
+
+	// check reachability
+	if !sparse && len(d.Included) > 0 {
+		// prepare reached resources
+		reached := make([]bool, len(resources))
+		queue := make([]int, 0, len(resources))
+		for i := range primary {
+			reached[i] = true
+			queue = append(queue, i)
+		}
+
+		// follow relationships
+		for len(queue) > 0 {
+			res := resources[queue[0]]
+			queue = queue[1:]
+			if res == nil {
+				continue
+			}
+			for _, rel := range res.Relationships {
+				eachLinkage(rel, "", func(id *Resource, _ string) {
+					if id == nil {
+						return
+					}
+					key, ok := keyOf(id)
+					if !ok {
+						return
+					}
+					j, ok := index[key]
+					if ok && !reached[j] {
+						reached[j] = true
+						queue = append(queue, j)
+					}
+				})
+			}
+		}
+
+		// check included resources
+		for i := len(primary); i < len(resources); i++ {
+			if !reached[i] && !duplicates[i] && resources[i] != nil {
+				errs = append(errs, BadRequestPointer("included resource is not linked", pointers[i]))
+			}
+		}
+	}
+
+	return errs
+}
+
+// eachLinkage will yield all resource identifiers of the passed relationship.
+func eachLinkage(rel *Relationship, pointer string, fn func(*Resource, string)) {
+	// check data
+	if rel == nil || rel.Data == nil {
+		return
+	}
+
+	// yield single identifier
+	if rel.Data.One != nil {
+		fn(rel.Data.One, pointer)
+		return
+	}
+
+	// yield identifiers
+	for i, id := range rel.Data.Many {
+		fn(id, pointer+"/"+strconv.Itoa(i))
+	}
+}
+
+// sortedRelationships will return the sorted relationship names of the passed
+// resource.
+func sortedRelationships(res *Resource) []string {
+	names := make([]string, 0, len(res.Relationships))
+	for name := range res.Relationships {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// escapePointer will escape the passed member name for use in a JSON pointer.
+func escapePointer(name string) string {
+	name = strings.Replace(name, "~", "~0", -1)
+	return strings.Replace(name, "/", "~1", -1)
+}
+
+// ValidateDocument will check the structure of the passed raw JSON API
+// document and return a list of errors with pointers to the offending members.
+// The following rules are checked:
+//
+//   - Top-level members: The document must contain "data", "errors" or
+//     "meta", must not contain both "data" and "errors", must not contain
+//     "included" without "data" and must not contain unknown members.
+//   - Resource objects: Resources must have a "type", included resources must
+//     have an "id" or "lid", but not both and only known members are allowed.
+//     Attributes and relationships must not be named "type" or "id" and must
+//     not share names. Objects in attribute values must not contain
+//     "relationships" or "links" members.
+//   - Relationship objects: Relationships must contain "data", "links" or
+//     "meta" and their data must be null, a resource identifier or a list of
+//     resource identifiers.
+//   - Member names: All member names of attributes, relationships and meta
+//     objects must follow the member name rules of the specification.
+//
+// Note: The returned errors can directly be written using WriteErrorList.
+func ValidateDocument(data []byte) []*Error {
+	// prepare decoder
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	// decode document
+	var doc interface{}
+	err := dec.Decode(&doc)
+	if err != nil {
+		return []*Error{BadRequest(err.Error())}
+	}
+
+	// prepare validator
+	v := &validator{}
+
+	// validate document
+	v.document(doc)
+
+	return v.errs
+}
+
+type validator struct {
+	errs []*Error
+}
+
+func (v *validator) fail(detail, pointer string) {
+	v.errs = append(v.errs, BadRequestPointer(detail, pointer))
+}
+
+func (v *validator) document(value interface{}) {
+	// check object
+	doc, ok := value.(map[string]interface{})
+	if !ok {
+		v.errs = append(v.errs, BadRequest("document is not an object"))
+		return
+	}
+
+	// check required members
+	_, hasData := doc["data"]
+	_, hasErrors := doc["errors"]
+	_, hasMeta := doc["meta"]
+	if !hasData && !hasErrors && !hasMeta && !hasExtensionMember(doc) {
+		v.errs = append(v.errs, BadRequest("document must contain data, errors or meta"))
+	}
+
+	// check conflicting members
+	if hasData && hasErrors {
+		v.fail("document must not contain both data and errors", "/errors")
+	}
+
+	// check included
+	if _, ok := doc["included"]; ok && !hasData {
+		v.fail("document must not contain included without data", "/included")
+	}
+
+	// check members
+	for _, name := range sortedKeys(doc) {
+		pointer := "/" + escapePointer(name)
+		value := doc[name]
+
+		switch name {
+		case "data":
+			switch data := value.(type) {
+			case nil:
+			case map[string]interface{}:
+				v.resource(data, pointer, false)
+			case []interface{}:
+				for i, item := range data {
+					v.resource(item, pointer+"/"+strconv.Itoa(i), false)
+				}
+			default:
+				v.fail("data must be null, an object or an array", pointer)
+			}
+		case "included":
+			list, ok := value.([]interface{})
+			if !ok {
+				v.fail("included must be an array", pointer)
+				continue
+			}
+			for i, item := range list {
+				v.resource(item, pointer+"/"+strconv.Itoa(i), true)
+			}
+		case "errors":
+			list, ok := value.([]interface{})
+			if !ok {
+				v.fail("errors must be an array", pointer)
+				continue
+			}
+			for i, item := range list {
+				if _, ok := item.(map[string]interface{}); !ok {
+					v.fail("error must be an object", pointer+"/"+strconv.Itoa(i))
+				}
+			}
+		case "meta":
+			v.meta(value, pointer)
+		case "links":
+			v.links(value, pointer)
+		case "jsonapi":
+			v.jsonapi(value, pointer)
+		default:
+			if !isSpecialMember(name) {
+				v.fail("invalid top-level member", pointer)
+			}
+		}
+	}
+}
+
+func (v *validator) resource(value interface{}, pointer string, included bool) {
+	// check object
+	res, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail("resource must be an object", pointer)
+		return
+	}
+
+	// check type
+	if typ, ok := res["type"].(string); !ok || typ == "" {
+		v.fail("resource must have a type", pointer+"/type")
+	}
+
+	// check id and lid
+	id, hasID := res["id"]
+	lid, hasLID := res["lid"]
+	if hasID {
+		if _, ok := id.(string); !ok {
+			v.fail("resource id must be a string", pointer+"/id")
+		}
+	}
+	if hasLID {
+		if str, ok := lid.(string); !ok || str == "" {
+			v.fail("resource lid must be a non-empty string", pointer+"/lid")
+		} else if hasID {
+			v.fail("resource must not have both id and lid", pointer+"/lid")
+		}
+	}
+	if included && !hasID && !hasLID {
+		v.fail("included resource must have an id or lid", pointer+"/id")
+	}
+
+	// get attributes and relationships
+	attributes, _ := res["attributes"].(map[string]interface{})
+	relationships, _ := res["relationships"].(map[string]interface{})
+
+	// check members
+	for _, name := range sortedKeys(res) {
+		pointer := pointer + "/" + escapePointer(name)
+		value := res[name]
+
+		switch name {
+		case "type", "id", "lid":
+		case "attributes":
+			if attributes == nil {
+				v.fail("attributes must be an object", pointer)
+				continue
+			}
+			for _, name := range sortedKeys(attributes) {
+				pointer := pointer + "/" + escapePointer(name)
+				v.field(name, pointer)
+				v.members(attributes[name], pointer, true)
+			}
+		case "relationships":
+			if relationships == nil {
+				v.fail("relationships must be an object", pointer)
+				continue
+			}
+			for _, name := range sortedKeys(relationships) {
+				pointer := pointer + "/" + escapePointer(name)
+				v.field(name, pointer)
+				if _, ok := attributes[name]; ok {
+					v.fail("relationship name conflicts with attribute", pointer)
+				}
+				v.relationship(relationships[name], pointer)
+			}
+		case "links":
+			v.links(value, pointer)
+		case "meta":
+			v.meta(value, pointer)
+		default:
+			if !isSpecialMember(name) {
+				v.fail("invalid resource member", pointer)
+			}
+		}
+	}
+}
+
+func (v *validator) relationship(value interface{}, pointer string) {
+	// check object
+	rel, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail("relationship must be an object", pointer)
+		return
+	}
+
+	// check required members
+	_, hasData := rel["data"]
+	_, hasLinks := rel["links"]
+	_, hasMeta := rel["meta"]
+	if !hasData && !hasLinks && !hasMeta {
+		v.fail("relationship must contain data, links or meta", pointer)
+	}
+
+	// check members
+	for _, name := range sortedKeys(rel) {
+		pointer := pointer + "/" + escapePointer(name)
+		value := rel[name]
+
+		switch name {
+		case "data":
+			switch data := value.(type) {
+			case nil:
+			case map[string]interface{}:
+				v.identifier(data, pointer)
+			case []interface{}:
+				for i, item := range data {
+					v.identifier(item, pointer+"/"+strconv.Itoa(i))
+				}
+			default:
+				v.fail("relationship data must be null, an object or an array", pointer)
+			}
+		case "links":
+			v.links(value, pointer)
+		case "meta":
+			v.meta(value, pointer)
+		default:
+			if !isSpecialMember(name) {
+				v.fail("invalid relationship member", pointer)
+			}
+		}
+	}
+}
+
+func (v *validator) identifier(value interface{}, pointer string) {
+	// check object
+	id, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail("resource identifier must be an object", pointer)
+		return
+	}
+
+	// check type
+	if typ, ok := id["type"].(string); !ok || typ == "" {
+		v.fail("resource identifier must have a type", pointer+"/type")
+	}
+
+	// check id and lid
+	str, hasID := id["id"].(string)
+	lid, hasLID := id["lid"]
+	if hasLID {
+		if str, ok := lid.(string); !ok || str == "" {
+			v.fail("resource identifier lid must be a non-empty string", pointer+"/lid")
+		} else if hasID {
+			v.fail("resource identifier must not have both id and lid", pointer+"/lid")
+		}
+	} else if !hasID || str == "" {
+		v.fail("resource identifier must have an id or lid", pointer+"/id")
+	}
+
+	// check members
+	for _, name := range sortedKeys(id) {
+		switch name {
+		case "type", "id", "lid":
+		case "meta":
+			v.meta(id[name], pointer+"/meta")
+		default:
+			if !isSpecialMember(name) {
+				v.fail("invalid resource identifier member", pointer+"/"+escapePointer(name))
+			}
+		}
+	}
+}
+
+func (v *validator) jsonapi(value interface{}, pointer string) {
+	// check object
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail("jsonapi must be an object", pointer)
+		return
+	}
+
+	// check members
+	for _, name := range sortedKeys(obj) {
+		pointer := pointer + "/" + escapePointer(name)
+		value := obj[name]
+
+		switch name {
+		case "version":
+			if _, ok := value.(string); !ok {
+				v.fail("jsonapi version must be a string", pointer)
+			}
+		case "ext", "profile":
+			list, ok := value.([]interface{})
+			if !ok {
+				v.fail("jsonapi "+name+" must be an array", pointer)
+				continue
+			}
+			for i, item := range list {
+				if _, ok := item.(string); !ok {
+					v.fail("jsonapi "+name+" must only contain strings", pointer+"/"+strconv.Itoa(i))
+				}
+			}
+		case "meta":
+			v.meta(value, pointer)
+		default:
+			if !isSpecialMember(name) {
+				v.fail("invalid jsonapi member", pointer)
+			}
+		}
+	}
+}
+
+func (v *validator) links(value interface{}, pointer string) {
+	// check object
+	links, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail("links must be an object", pointer)
+		return
+	}
+
+	// check links
+	for _, name := range sortedKeys(links) {
+		v.link(links[name], pointer+"/"+escapePointer(name))
+	}
+}
+
+func (v *validator) link(value interface{}, pointer string) {
+	// check value
+	var obj map[string]interface{}
+	switch value := value.(type) {
+	case nil, string:
+		return
+	case map[string]interface{}:
+		obj = value
+	default:
+		v.fail("link must be null, a string or an object", pointer)
+		return
+	}
+
+	// check href
+	if _, ok := obj["href"].(string); !ok {
+		v.fail("link must have an href", pointer+"/href")
+	}
+
+	// check members
+	for _, name := range sortedKeys(obj) {
+		pointer := pointer + "/" + escapePointer(name)
+		value := obj[name]
+
+		switch name {
+		case "href":
+		case "rel", "title", "type":
+			if _, ok := value.(string); !ok {
+				v.fail("link "+name+" must be a string", pointer)
+			}
+		case "hreflang":
+			switch lang := value.(type) {
+			case string:
+			case []interface{}:
+				for i, item := range lang {
+					if _, ok := item.(string); !ok {
+						v.fail("link hreflang must only contain strings", pointer+"/"+strconv.Itoa(i))
+					}
+				}
+			default:
+				v.fail("link hreflang must be a string or an array", pointer)
+			}
+		case "describedby":
+			v.link(value, pointer)
+		case "meta":
+			v.meta(value, pointer)
+		default:
+			if !isSpecialMember(name) {
+				v.fail("invalid link member", pointer)
+			}
+		}
+	}
+}
+
+func (v *validator) meta(value interface{}, pointer string) {
+	// check object
+	if _, ok := value.(map[string]interface{}); !ok {
+		v.fail("meta must be an object", pointer)
+		return
+	}
+
+	// check members
+	v.members(value, pointer, false)
+}
+
+func (v *validator) field(name, pointer string) {
+	// check name
+	if !isValidMemberName(name) {
+		v.fail("invalid member name", pointer)
+	} else if name == "type" || name == "id" {
+		v.fail("field name is reserved", pointer)
+	}
+}
+
+// members will check the member names of the passed value recursively.
+// Objects in attribute values must not contain "relationships" or "links".
+func (v *validator) members(value interface{}, pointer string, attribute bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, name := range sortedKeys(value) {
+			pointer := pointer + "/" + escapePointer(name)
+			if !isValidMemberName(name) {
+				v.fail("invalid member name", pointer)
+			} else if attribute && (name == "relationships" || name == "links") {
+				v.fail("attribute member name is reserved", pointer)
+			}
+			v.members(value[name], pointer, attribute)
+		}
+	case []interface{}:
+		for i, item := range value {
+			v.members(item, pointer+"/"+strconv.Itoa(i), attribute)
+		}
+	}
+}
+
+// isValidMemberName returns whether the passed name follows the member name
+// rules of the specification.
+//
+// See: https://jsonapi.org/format/#document-member-names.
+func isValidMemberName(name string) bool {
+	// check length
+	if name == "" {
+		return false
+	}
+
+	// check characters
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r >= 0x80:
+		case r == '-' || r == '_' || r == ' ':
+			if i == 0 || i == len(runes)-1 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSpecialMember returns whether the passed name is an @-member or an
+// extension member which may appear in any object.
+func isSpecialMember(name string) bool {
+	// check @-member
+	if strings.HasPrefix(name, "@") {
+		return isValidMemberName(name[1:])
+	}
+
+	// check extension member
+	if i := strings.Index(name, ":"); i > 0 {
+		return isValidMemberName(name[:i]) && isValidMemberName(name[i+1:])
+	}
+
+	return false
+}
+
+// hasExtensionMember returns whether the passed object contains an extension
+// member.
+func hasExtensionMember(obj map[string]interface{}) bool {
+	for name := range obj {
+		if strings.Contains(name, ":") && isSpecialMember(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortedKeys will return the sorted keys of the passed object.
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
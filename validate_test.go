@@ -0,0 +1,517 @@
+package jsonapi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentValidate(t *testing.T) {
+	doc := &Document{
+		Data: &HybridResource{
+			Many: []*Resource{
+				{
+					Type: "posts",
+					ID:   "1",
+					Relationships: map[string]*Relationship{
+						"author": {
+							Data: &HybridResource{
+								One: &Resource{Type: "users", ID: "1"},
+							},
+						},
+					},
+				},
+			},
+		},
+		Included: []*Resource{
+			{
+				Type: "users",
+				ID:   "1",
+				Relationships: map[string]*Relationship{
+					"company": {
+						Data: &HybridResource{
+							One: &Resource{Type: "companies", ID: "1"},
+						},
+					},
+				},
+			},
+			{
+				Type: "companies",
+				ID:   "1",
+			},
+		},
+	}
+	assert.Empty(t, doc.Validate())
+
+	assert.Empty(t, (&Document{}).Validate())
+	assert.Empty(t, (&Document{Data: &HybridResource{}}).Validate())
+}
+
+func TestDocumentValidateErrors(t *testing.T) {
+	doc := &Document{
+		Data: &HybridResource{
+			One: &Resource{
+				Type: "posts",
+				ID:   "1",
+				Relationships: map[string]*Relationship{
+					"author": {
+						Data: &HybridResource{
+							One: &Resource{Type: "users"},
+						},
+					},
+					"tags/all": {
+						Data: &HybridResource{
+							Many: []*Resource{
+								{Type: "tags", ID: "1"},
+								{ID: "2"},
+							},
+						},
+					},
+				},
+			},
+		},
+		Included: []*Resource{
+			{Type: "tags", ID: "1"},
+			{Type: "users", ID: "1"},
+			{Type: "tags", ID: "1"},
+			{Type: "posts", ID: "1"},
+		},
+	}
+	assert.Equal(t, []*Error{
+		BadRequestPointer("duplicate resource", "/included/2"),
+		BadRequestPointer("duplicate resource", "/included/3"),
+		BadRequestPointer("dangling resource reference", "/data/relationships/author/data"),
+		BadRequestPointer("dangling resource reference", "/data/relationships/tags~1all/data/1"),
+		BadRequestPointer("included resource is not linked", "/included/1"),
+	}, doc.Validate())
+
+	doc = &Document{
+		Included: []*Resource{
+			{Type: "users", ID: "1"},
+		},
+	}
+	assert.Equal(t, []*Error{
+		BadRequestPointer("included resources without primary data", "/included"),
+		BadRequestPointer("included resource is not linked", "/included/0"),
+	}, doc.Validate())
+}
+
+func TestDocumentValidateLocalIDs(t *testing.T) {
+	doc := &Document{
+		Data: &HybridResource{
+			One: &Resource{
+				Type: "posts",
+				LID:  "a",
+				Relationships: map[string]*Relationship{
+					"author": {
+						Data: &HybridResource{
+							One: &Resource{Type: "users", LID: "b"},
+						},
+					},
+				},
+			},
+		},
+		Included: []*Resource{
+			{Type: "users", LID: "b"},
+			{Type: "users", ID: "b"},
+			{Type: "users", LID: "b"},
+		},
+	}
+	assert.Equal(t, []*Error{
+		BadRequestPointer("duplicate resource", "/included/2"),
+		BadRequestPointer("included resource is not linked", "/included/1"),
+	}, doc.Validate())
+}
+
+func TestDocumentValidateUnresolvedLocalIDs(t *testing.T) {
+	body := `{
+		"data": {
+			"type": "posts",
+			"lid": "a",
+			"relationships": {
+				"author": {
+					"data": { "type": "users", "lid": "b" }
+				},
+				"tags": {
+					"data": [
+						{ "type": "tags", "lid": "c" },
+						{ "type": "tags", "lid": "a" }
+					]
+				}
+			}
+		},
+		"included": [
+			{
+				"type": "tags",
+				"lid": "c"
+			}
+		]
+	}`
+
+	doc, err := ParseDocument(strings.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, []*Error{
+		BadRequestPointer("unresolved local id", "/data/relationships/author/data/lid"),
+		BadRequestPointer("unresolved local id", "/data/relationships/tags/data/1/lid"),
+	}, doc.Validate())
+
+	parser := &Parser{StrictDocuments: true}
+	doc, err = parser.ParseDocument(strings.NewReader(body))
+	assert.Equal(t, BadRequestPointer("unresolved local id", "/data/relationships/author/data/lid"), err)
+	assert.Nil(t, doc)
+}
+
+func TestDocumentValidateFields(t *testing.T) {
+	doc := &Document{
+		Data: &HybridResource{
+			One: &Resource{
+				Type: "posts",
+				ID:   "1",
+			},
+		},
+		Included: []*Resource{
+			{Type: "users", ID: "1"},
+		},
+	}
+	assert.Len(t, doc.Validate(), 1)
+	assert.Len(t, doc.ValidateFields(map[string][]string{"comments": {}}), 1)
+	assert.Empty(t, doc.ValidateFields(map[string][]string{"posts": {"title"}}))
+}
+
+func TestParserParseDocumentStrict(t *testing.T) {
+	body := `{
+		"data": {
+			"type": "posts",
+			"id": "1"
+		},
+		"included": [
+			{
+				"type": "users",
+				"id": "1"
+			}
+		]
+	}`
+
+	doc, err := ParseDocument(strings.NewReader(body))
+	assert.NoError(t, err)
+	assert.NotNil(t, doc)
+
+	parser := &Parser{StrictDocuments: true}
+	doc, err = parser.ParseDocument(strings.NewReader(body))
+	assert.Equal(t, BadRequestPointer("included resource is not linked", "/included/0"), err)
+	assert.Nil(t, doc)
+}
+
+func TestValidateDocument(t *testing.T) {
+	assert.Empty(t, ValidateDocument([]byte(`{
+		"jsonapi": {
+			"version": "1.0"
+		},
+		"links": {
+			"self": "/posts",
+			"next": null
+		},
+		"data": [
+			{
+				"type": "posts",
+				"id": "1",
+				"attributes": {
+					"title": "Hello",
+					"full-text": {
+						"en_US": "Hello World"
+					}
+				},
+				"relationships": {
+					"author": {
+						"data": {
+							"type": "users",
+							"id": "1"
+						}
+					},
+					"comments": {
+						"links": {
+							"related": "/posts/1/comments"
+						}
+					},
+					"tags": {
+						"data": []
+					},
+					"editor": {
+						"data": null
+					}
+				},
+				"links": {
+					"self": "/posts/1"
+				},
+				"meta": {
+					"version": 1
+				}
+			}
+		],
+		"included": [
+			{
+				"type": "users",
+				"id": "1"
+			}
+		],
+		"meta": {
+			"total": 1
+		}
+	}`)))
+
+	assert.Empty(t, ValidateDocument([]byte(`{
+		"data": {
+			"type": "posts"
+		}
+	}`)))
+
+	assert.Empty(t, ValidateDocument([]byte(`{
+		"errors": [{
+			"status": "404"
+		}]
+	}`)))
+
+	assert.Empty(t, ValidateDocument([]byte(`{
+		"data": null,
+		"@context": "foo"
+	}`)))
+}
+
+func TestValidateDocumentErrors(t *testing.T) {
+	assert.Equal(t, []*Error{
+		BadRequest("unexpected EOF"),
+	}, ValidateDocument([]byte(`{`)))
+
+	assert.Equal(t, []*Error{
+		BadRequest("document is not an object"),
+	}, ValidateDocument([]byte(`[]`)))
+
+	assert.Equal(t, []*Error{
+		BadRequest("document must contain data, errors or meta"),
+		BadRequestPointer("invalid top-level member", "/foo"),
+	}, ValidateDocument([]byte(`{
+		"foo": "bar"
+	}`)))
+
+	assert.Equal(t, []*Error{
+		BadRequestPointer("document must not contain both data and errors", "/errors"),
+	}, ValidateDocument([]byte(`{
+		"data": null,
+		"errors": []
+	}`)))
+
+	assert.Equal(t, []*Error{
+		BadRequestPointer("document must not contain included without data", "/included"),
+		BadRequestPointer("included resource must have an id or lid", "/included/0/id"),
+	}, ValidateDocument([]byte(`{
+		"meta": {},
+		"included": [{
+			"type": "users"
+		}]
+	}`)))
+
+	assert.Equal(t, []*Error{
+		BadRequestPointer("data must be null, an object or an array", "/data"),
+		BadRequestPointer("links must be an object", "/links"),
+		BadRequestPointer("meta must be an object", "/meta"),
+	}, ValidateDocument([]byte(`{
+		"data": "foo",
+		"links": "bar",
+		"meta": true
+	}`)))
+
+	assert.Equal(t, []*Error{
+		BadRequestPointer("resource must have a type", "/data/type"),
+		BadRequestPointer("resource id must be a string", "/data/id"),
+		BadRequestPointer("invalid member name", "/data/attributes/-foo"),
+		BadRequestPointer("attribute member name is reserved", "/data/attributes/list/0/relationships"),
+		BadRequestPointer("invalid member name", "/data/attributes/nested/bar$"),
+		BadRequestPointer("attribute member name is reserved", "/data/attributes/nested/links"),
+		BadRequestPointer("field name is reserved", "/data/attributes/type"),
+		BadRequestPointer("invalid resource member", "/data/foo"),
+		BadRequestPointer("invalid member name", "/data/meta/a~1b"),
+		BadRequestPointer("relationship name conflicts with attribute", "/data/relationships/nested"),
+		BadRequestPointer("relationship must contain data, links or meta", "/data/relationships/nested"),
+		BadRequestPointer("resource identifier must have an id or lid", "/data/relationships/posts/data/0/id"),
+		BadRequestPointer("invalid resource identifier member", "/data/relationships/posts/data/0/attributes"),
+		BadRequestPointer("relationship data must be null, an object or an array", "/data/relationships/tags/data"),
+		BadRequestPointer("invalid relationship member", "/data/relationships/tags/included"),
+	}, ValidateDocument([]byte(`{
+		"data": {
+			"id": 1,
+			"attributes": {
+				"-foo": "bar",
+				"links": "bar",
+				"list": [{
+					"relationships": {}
+				}],
+				"type": "bar",
+				"nested": {
+					"bar$": true,
+					"links": {}
+				}
+			},
+			"relationships": {
+				"nested": {},
+				"posts": {
+					"data": [{
+						"type": "posts",
+						"attributes": {}
+					}]
+				},
+				"tags": {
+					"data": "foo",
+					"included": []
+				}
+			},
+			"meta": {
+				"a/b": 1
+			},
+			"foo": "bar"
+		}
+	}`)))
+}
+
+func TestValidateDocumentLinks(t *testing.T) {
+	assert.Equal(t, []*Error{
+		BadRequestPointer("link must be null, a string or an object", "/links/first"),
+		BadRequestPointer("link must have an href", "/links/next/href"),
+		BadRequestPointer("invalid link member", "/links/next/foo"),
+		BadRequestPointer("link hreflang must only contain strings", "/links/next/hreflang/1"),
+		BadRequestPointer("link title must be a string", "/links/self/title"),
+	}, ValidateDocument([]byte(`{
+		"meta": {},
+		"links": {
+			"self": {
+				"href": "/posts",
+				"title": 1,
+				"describedby": "/schemas/posts"
+			},
+			"first": 1,
+			"next": {
+				"hreflang": ["en", 1],
+				"foo": "bar"
+			}
+		}
+	}`)))
+}
+
+func TestValidateDocumentJSONAPI(t *testing.T) {
+	assert.Equal(t, []*Error{
+		BadRequestPointer("jsonapi ext must only contain strings", "/jsonapi/ext/1"),
+		BadRequestPointer("invalid jsonapi member", "/jsonapi/foo"),
+		BadRequestPointer("meta must be an object", "/jsonapi/meta"),
+		BadRequestPointer("jsonapi profile must be an array", "/jsonapi/profile"),
+		BadRequestPointer("jsonapi version must be a string", "/jsonapi/version"),
+	}, ValidateDocument([]byte(`{
+		"meta": {},
+		"jsonapi": {
+			"version": 1.1,
+			"ext": ["foo", 1],
+			"profile": "bar",
+			"meta": [],
+			"foo": "bar"
+		}
+	}`)))
+}
+
+func TestValidateDocumentLocalIDs(t *testing.T) {
+	assert.Empty(t, ValidateDocument([]byte(`{
+		"data": {
+			"type": "posts",
+			"lid": "a",
+			"relationships": {
+				"author": {
+					"data": {
+						"type": "users",
+						"lid": "b"
+					}
+				}
+			}
+		},
+		"included": [{
+			"type": "users",
+			"lid": "b"
+		}]
+	}`)))
+
+	assert.Equal(t, []*Error{
+		BadRequestPointer("resource must not have both id and lid", "/data/lid"),
+		BadRequestPointer("resource identifier must not have both id and lid", "/data/relationships/author/data/lid"),
+		BadRequestPointer("resource identifier lid must be a non-empty string", "/data/relationships/tags/data/0/lid"),
+		BadRequestPointer("resource lid must be a non-empty string", "/included/0/lid"),
+	}, ValidateDocument([]byte(`{
+		"data": {
+			"type": "posts",
+			"id": "1",
+			"lid": "a",
+			"relationships": {
+				"author": {
+					"data": {
+						"type": "users",
+						"id": "1",
+						"lid": "b"
+					}
+				},
+				"tags": {
+					"data": [{
+						"type": "tags",
+						"lid": 1
+					}]
+				}
+			}
+		},
+		"included": [{
+			"type": "users",
+			"lid": ""
+		}]
+	}`)))
+}
+
+func TestIsValidMemberName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"":        false,
+		"foo":     true,
+		"fooBar":  true,
+		"foo-bar": true,
+		"foo_bar": true,
+		"foo bar": true,
+		"föö":     true,
+		"-foo":    false,
+		"foo_":    false,
+		" foo":    false,
+		"foo.bar": false,
+		"foo/bar": false,
+		"foo@bar": false,
+		"foo:bar": false,
+		"123":     true,
+	} {
+		assert.Equal(t, valid, isValidMemberName(name), name)
+	}
+}
+
+func TestParserParseDocumentStrictStructure(t *testing.T) {
+	parser := &Parser{StrictDocuments: true}
+
+	doc, err := parser.ParseDocument(strings.NewReader(`{
+		"data": {
+			"type": "posts",
+			"relationships": {
+				"author": {}
+			}
+		}
+	}`))
+	assert.Equal(t, BadRequestPointer("relationship must contain data, links or meta", "/data/relationships/author"), err)
+	assert.Nil(t, doc)
+}
+
+func TestParserParseDocumentStrictReadError(t *testing.T) {
+	parser := &Parser{StrictDocuments: true}
+
+	doc, err := parser.ParseDocument(iotest.ErrReader(errors.New("foo")))
+	assert.Equal(t, BadRequest("foo"), err)
+	assert.Nil(t, doc)
+}